@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"src/internal/api"
+	"src/internal/ml"
+	pl "src/utils/packetsLogic"
+	"strconv"
+)
+
+// handleCancelMission serves DELETE /api/missions/{id}
+// A queued mission is simply never assigned; an assigned one is recalled from its rover with
+// MSG_CANCEL, and the rover answers with a last report flagged as aborted
+func (ms *MotherShip) handleCancelMission(vars map[string]string, _ []byte) (interface{}, error) {
+	id, err := strconv.ParseUint(vars["id"], 10, 16)
+	if err != nil {
+		return nil, api.NewHTTPError(http.StatusBadRequest, "invalid mission id %q", vars["id"])
+	}
+
+	mission, err := ms.MissionManager.CancelMission(uint16(id))
+	switch {
+	case errors.Is(err, ml.ErrMissionNotFound):
+		return nil, api.NewHTTPError(http.StatusNotFound, "mission %d not found", id)
+	case errors.Is(err, ml.ErrMissionFinished):
+		return nil, api.NewHTTPError(http.StatusConflict, "mission %d is already %s", id, mission.State)
+	case err != nil:
+		return nil, err
+	}
+
+	ms.Scheduler.Remove(mission.ID)
+	ms.Logger.Warnf("ML", "🛑 Mission %d cancelled", mission.ID)
+	if mission.IDRover != 0 {
+		ms.sendCancel(mission)
+	}
+	ms.publishMissionEvents(&mission, "mission_cancelled")
+	return mission, nil
+}
+
+// sendCancel tells the rover running a mission to abort it
+func (ms *MotherShip) sendCancel(mission ml.MissionState) {
+	ms.Mu.Lock()
+	state := ms.Rovers[mission.IDRover]
+	ms.Mu.Unlock()
+	if state == nil {
+		ms.Logger.Warnf("ML", "⚠️ Rover %d has no session, not told to drop mission %d", mission.IDRover, mission.ID)
+		return
+	}
+
+	cancel := ml.CancelData{MissionID: mission.ID}
+	// Not sent from the API goroutine: it may have to wait for a window slot
+	go pl.CreateAndSendPacket(
+		context.Background(),
+		ms.Conn,
+		state.GetAddr(),
+		0,
+		ml.MSG_CANCEL,
+		&state.SeqNum,
+		0,
+		cancel.Encode(),
+		state.Window,
+		&state.WindowLock,
+		ms.Logger.CreateLogCallback("ML"),
+	)
+	ms.Logger.Infof("ML", "📨 Cancellation of mission %d sent to rover %d", mission.ID, mission.IDRover)
+}
@@ -1,31 +1,83 @@
 package main
 
 import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"math"
 	"net"
 	"src/config"
+	"src/internal/ml"
 	"src/internal/ts"
 	"src/utils"
 	"sync"
+	"time"
+)
+
+// Errors returned by the IDManager
+var (
+	ErrIDsExhausted   = errors.New("no rover IDs left")
+	ErrIDNotAssigned  = errors.New("ID never assigned by this mothership")
+	ErrReclaimRefused = errors.New("reclaim token doesn't match the last assignment of the ID")
 )
 
 // IDManager manages the assignment of unique IDs to rovers
 type IDManager struct {
-	nextID uint8      // Next available ID to assign
-	mu     sync.Mutex // Mutex for concurrent access
+	nextID int                                 // Next available ID to assign (past math.MaxUint8 once exhausted)
+	tokens map[uint8][ml.ReclaimTokenSize]byte // Token issued with the last assignment of each ID
+	mu     sync.Mutex                          // Mutex for concurrent access
 }
 
 // NewIDManager creates and initializes a new IDManager instance
 func NewIDManager() *IDManager {
-	return &IDManager{nextID: 1}
+	return &IDManager{nextID: 1, tokens: make(map[uint8][ml.ReclaimTokenSize]byte)}
 }
 
 // GetNextID returns the next available unique ID and increments the counter
-func (idm *IDManager) GetNextID() uint8 {
+// IDs never wrap around: once 255 is taken, ErrIDsExhausted is returned
+// Also returns the token the rover needs to reclaim the ID after a restart
+func (idm *IDManager) GetNextID() (uint8, [ml.ReclaimTokenSize]byte, error) {
 	idm.mu.Lock()
 	defer idm.mu.Unlock()
-	id := idm.nextID
+	if idm.nextID > math.MaxUint8 {
+		return 0, [ml.ReclaimTokenSize]byte{}, ErrIDsExhausted
+	}
+	id := uint8(idm.nextID)
+	token, err := idm.issueToken(id)
+	if err != nil {
+		return 0, token, err
+	}
 	idm.nextID++
-	return id
+	return id, token, nil
+}
+
+// Reclaim grants a rover the ID it asked for (a restarted rover keeps its identity)
+// The rover proves it held the ID with the token of its last assignment. Only IDs this
+// mothership assigned can be reclaimed: tokens aren't kept across its restarts
+// Returns the token of the new assignment: the old one can't be used again
+func (idm *IDManager) Reclaim(id uint8, token [ml.ReclaimTokenSize]byte) ([ml.ReclaimTokenSize]byte, error) {
+	idm.mu.Lock()
+	defer idm.mu.Unlock()
+	issued, ok := idm.tokens[id]
+	if !ok {
+		return [ml.ReclaimTokenSize]byte{}, ErrIDNotAssigned
+	}
+	if subtle.ConstantTimeCompare(issued[:], token[:]) != 1 {
+		return [ml.ReclaimTokenSize]byte{}, ErrReclaimRefused
+	}
+	return idm.issueToken(id)
+}
+
+// issueToken records a new token for an assignment of the ID
+// Caller must hold idm.mu
+func (idm *IDManager) issueToken(id uint8) ([ml.ReclaimTokenSize]byte, error) {
+	token, err := ml.NewReclaimToken()
+	if err != nil {
+		return token, fmt.Errorf("error generating reclaim token: %v", err)
+	}
+	idm.tokens[id] = token
+	return token, nil
 }
 
 // idAssignmentServer starts a TCP server to handle ID assignment requests from rovers
@@ -59,20 +111,81 @@ func (ms *MotherShip) idAssignmentServer(port string) {
 func (ms *MotherShip) handleIDRequest(conn net.Conn, idManager *IDManager) {
 	defer conn.Close()
 
-	id := idManager.GetNextID()
+	// Read the rover's ephemeral X25519 public key and requested ID
+	buf := make([]byte, ml.IDRequestSize)
+	_ = conn.SetReadDeadline(time.Now().Add(config.TCP_TIMEOUT))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		ms.Logger.Errorf("IDHandler", "Error reading rover public key: %v", err)
+		return
+	}
+	var request ml.IDRequest
+	if err := request.Decode(buf); err != nil {
+		ms.Logger.Errorf("IDHandler", "Invalid ID request: %v", err)
+		return
+	}
+
+	// Negotiate the session keys used to authenticate (and optionally encrypt) MissionLink packets
+	privateKey, err := ml.NewHandshakeKey()
+	if err != nil {
+		ms.Logger.Errorf("IDHandler", "Error generating handshake key: %v", err)
+		return
+	}
+	epoch, err := ml.NewEpoch()
+	if err != nil {
+		ms.Logger.Errorf("IDHandler", "Error generating session epoch: %v", err)
+		return
+	}
+	keys, err := ml.DeriveSessionKeys(privateKey, request.PublicKey[:], epoch, config.ENCRYPTION_ENABLED)
+	if err != nil {
+		ms.Logger.Errorf("IDHandler", "Error negotiating session keys: %v", err)
+		return
+	}
+
+	var token [ml.ReclaimTokenSize]byte
+	id := request.RequestedID
+	if id != 0 {
+		// A rover that is still up keeps its ID: the one asking for it must wait until its
+		// session is gone (a restarted rover) or ask for a new one (a copy)
+		if ms.hasLiveSession(id) {
+			err = fmt.Errorf("rover %d still has a live session", id)
+		} else {
+			token, err = idManager.Reclaim(id, request.ReclaimToken)
+		}
+		if err != nil {
+			ms.Logger.Warnf("IDHandler", "⚠️ Reclaim of ID %d refused: %v", id, err)
+			refusal := ml.IDAssignment{}
+			_, _ = conn.Write(refusal.Encode())
+			return
+		}
+		ms.Logger.Infof("IDHandler", "Rover reclaimed ID %d", id)
+		ms.handleRoverRestart(id, epoch)
+	} else if id, token, err = idManager.GetNextID(); err != nil {
+		ms.Logger.Errorf("IDHandler", "Error assigning rover ID: %v", err)
+		return
+	}
 	// Get update frequency from config (convert from Duration to seconds)
 	updateFrequency := uint(config.DEFAULT_TELEMETRY_FREQ.Seconds())
 
-	// Send assigned ID and update frequency to rover
-	buf := []byte{id, byte(updateFrequency)}
-	_, err := conn.Write(buf)
+	// Send assigned ID, update frequency and handshake parameters to rover
+	assignment := ml.IDAssignment{
+		ID:              id,
+		UpdateFrequency: uint8(updateFrequency),
+		Epoch:           epoch,
+		Encrypted:       config.ENCRYPTION_ENABLED,
+		ReclaimToken:    token,
+	}
+	copy(assignment.PublicKey[:], privateKey.PublicKey().Bytes())
+	_, err = conn.Write(assignment.Encode())
 	if err != nil {
 		ms.Logger.Errorf("IDHandler", "Error sending ID/updateFrequency: %v", err)
 		return
 	}
 
+	// Only accept MissionLink packets from this rover once the keys are negotiated
+	ms.SetRoverKeys(id, keys)
+
 	// Log assignment and register rover in RoverInfo manager
-	ms.Logger.Infof("IDHandler", "ID %d assigned to new rover (updateFrequency=%d)", id, updateFrequency)
+	ms.Logger.Infof("IDHandler", "ID %d assigned to new rover (updateFrequency=%d, encrypted=%v)", id, updateFrequency, config.ENCRYPTION_ENABLED)
 	ms.RoverInfo.AddRover(&ts.RoverTSState{
 		ID:              id,
 		State:           "Unknown",
@@ -90,3 +203,13 @@ func (ms *MotherShip) handleIDRequest(conn net.Conn, idManager *IDManager) {
 		},
 	})
 }
+
+// hasLiveSession reports whether a rover's MissionLink session still answers the keepalive
+// Without keepalive a live session can't be told apart from one a crashed rover left behind,
+// so the reclaim token alone decides
+func (ms *MotherShip) hasLiveSession(roverID uint8) bool {
+	ms.Mu.Lock()
+	defer ms.Mu.Unlock()
+	state, ok := ms.Rovers[roverID]
+	return ok && state.Window.HasCapability(ml.CAP_KEEPALIVE) && state.MLHealth == ts.ML_HEALTHY
+}
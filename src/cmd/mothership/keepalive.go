@@ -0,0 +1,90 @@
+package main
+
+import (
+	"src/config"
+	"src/internal/core"
+	"src/internal/ml"
+	"src/internal/ts"
+	pl "src/utils/packetsLogic"
+	"time"
+)
+
+// keepalive periodically probes the MissionLink path of every rover with MSG_PING
+// Telemetry runs over TCP, so it can't tell when the UDP path is blackholed: a rover
+// that leaves KEEPALIVE_MAX_MISSED PINGs in a row unanswered is marked as degraded
+func (ms *MotherShip) keepalive() {
+	if config.KEEPALIVE_INTERVAL <= 0 {
+		ms.Logger.Infof("ML", "💤 MissionLink keepalive disabled")
+		return
+	}
+
+	ticker := time.NewTicker(config.KEEPALIVE_INTERVAL)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ms.probeRovers()
+	}
+}
+
+// probeRovers accounts for the PINGs left unanswered since the last round and sends new ones
+func (ms *MotherShip) probeRovers() {
+	ms.Mu.Lock()
+	defer ms.Mu.Unlock()
+
+	for roverID, state := range ms.Rovers {
+		// Rovers that didn't negotiate keepalive never answer a PING
+		if !state.Window.HasCapability(ml.CAP_KEEPALIVE) {
+			continue
+		}
+
+		if state.PingPending {
+			state.MissedPings++
+			if state.MissedPings >= config.KEEPALIVE_MAX_MISSED && state.MLHealth != ts.ML_DEGRADED {
+				ms.Logger.Warnf("ML", "📵 MissionLink with rover %d degraded (%d PINGs unanswered)", roverID, state.MissedPings)
+				ms.setMissionLinkHealth(roverID, state, ts.ML_DEGRADED)
+			}
+		}
+
+		state.PingSeq++
+		state.PingPending = true
+		ping := ml.PingData{Seq: state.PingSeq}
+		pl.SendPingData(ms.Conn, state.GetAddr(), ml.MSG_PING, ping, state.Window, 0, ms.Logger.CreateLogCallback("ML"))
+	}
+}
+
+// handlePong records a rover's answer to the last PING, restoring its MissionLink health
+func (ms *MotherShip) handlePong(pkt ml.Packet, state *core.RoverState, roverID uint8) {
+	var pong ml.PingData
+	if err := pong.Decode(pkt.Payload); err != nil {
+		ms.Logger.Errorf("ML", "❌ Invalid PONG from rover %d: %v", roverID, err)
+		return
+	}
+
+	ms.Mu.Lock()
+	defer ms.Mu.Unlock()
+
+	// PONGs of earlier PINGs arrive too late to prove the path is alive now
+	if !state.PingPending || pong.Seq != state.PingSeq {
+		return
+	}
+	state.PingPending = false
+	state.MissedPings = 0
+
+	if state.MLHealth != ts.ML_HEALTHY {
+		ms.Logger.Infof("ML", "📶 MissionLink with rover %d restored", roverID)
+		ms.setMissionLinkHealth(roverID, state, ts.ML_HEALTHY)
+	}
+}
+
+// setMissionLinkHealth records a health change and publishes it to the API
+// Caller must hold ms.Mu
+func (ms *MotherShip) setMissionLinkHealth(roverID uint8, state *core.RoverState, health string) {
+	state.MLHealth = health
+	ms.RoverInfo.SetMissionLink(roverID, health)
+
+	if ms.APIServer != nil {
+		if rover := ms.RoverInfo.GetRover(roverID); rover != nil {
+			ms.APIServer.PublishUpdate("rover_missionlink", rover)
+		}
+	}
+}
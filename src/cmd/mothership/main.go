@@ -31,6 +31,9 @@ func main() {
 		MotherShip: core.NewMotherShip(),
 	}
 
+	// Mission control endpoints that talk to the rovers
+	mothership.APIServer.RegisterHandler("/api/missions/{id}", "DELETE", mothership.handleCancelMission)
+
 	// Setup graceful shutdown to print metrics
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -46,6 +49,7 @@ func main() {
 	go mothership.idAssignmentServer(config.TCP_ID_PORT)       // TCP ID Attribution
 	go mothership.receiver(config.UDP_COMM_PORT)               // UDP Communication
 	go mothership.telemetryReceiver(config.TCP_TELEMETRY_PORT) // TCP Telemetry
+	go mothership.keepalive()                                  // MissionLink liveness
 
 	select {}
 }
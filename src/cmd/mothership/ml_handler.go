@@ -1,12 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net"
+	"src/config"
 	"src/internal/core"
 	"src/internal/ml"
 	"src/internal/ts"
 	"src/utils"
+	"src/utils/metrics"
 	pl "src/utils/packetsLogic"
 	"strconv"
 	"time"
@@ -22,10 +26,13 @@ func (ms *MotherShip) handlePacket(state *core.RoverState, pkt ml.Packet) {
 	}
 
 	// Determine packet handling options
-	isPureAck := pkt.MsgType == ml.MSG_ACK
+	isUnsequenced := pkt.MsgType.IsUnsequenced()
 	// Don't auto-ACK for REQUEST (response is MSG_MISSION which acts as implicit ACK)
-	// Don't auto-ACK for pure ACK packets
-	shouldAutoAck := pkt.MsgType != ml.MSG_REQUEST && !isPureAck
+	// Don't auto-ACK for HELLO (response is MSG_HELLO_ACK which acts as implicit ACK)
+	// Don't auto-ACK for OPEN (response is MSG_OPEN_ACK which acts as implicit ACK)
+	// Don't auto-ACK for pure ACK, error, path validation or keepalive packets
+	shouldAutoAck := pkt.MsgType != ml.MSG_REQUEST && pkt.MsgType != ml.MSG_HELLO &&
+		pkt.MsgType != ml.MSG_OPEN && !isUnsequenced
 
 	// Use the generic ordered packet handler
 	go pl.HandleOrderedPacket(
@@ -34,12 +41,12 @@ func (ms *MotherShip) handlePacket(state *core.RoverState, pkt ml.Packet) {
 		state.Buffer,
 		&state.WindowLock,
 		ms.Conn,
-		state.Addr,
+		state.GetAddr(),
 		state.Window,
 		0,
 		processor,
-		isPureAck,     // skipOrdering: only for pure ACKs
-		shouldAutoAck, // autoAck: send ACK for REPORT, not for REQUEST or ACK
+		isUnsequenced, // skipOrdering: only for pure ACKs and errors
+		shouldAutoAck, // autoAck: send ACK for REPORT, not for REQUEST, HELLO or ACK
 		ms.Logger.CreateLogCallback("ML"),
 	)
 }
@@ -70,6 +77,7 @@ func (ms *MotherShip) receiver(port string) {
 
 	// Buffer for incoming packets
 	buf := make([]byte, 65535)
+	logf := ms.Logger.CreateLogCallback("ML")
 
 	// Main loop to read packets
 	for {
@@ -79,16 +87,75 @@ func (ms *MotherShip) receiver(port string) {
 			continue
 		}
 
-		var packet ml.Packet
-		packet.Decode(buf[:n])
+		// Discard corrupted datagrams before trusting the RoverId
+		packet, ok := pl.DecodePacket(buf[:n], addr, logf)
+		if !ok {
+			continue
+		}
 		roverID := packet.RoverId
 
 		ms.Mu.Lock()
 		state, exists := ms.Rovers[roverID]
 
-		// If rover state does not exist, create it
-		if !exists {
-			ms.NewRoverState(roverID, addr, &packet, &state)
+		// Sessions are only created by a MSG_OPEN from a rover that completed the ID handshake
+		// An OPEN with a new ISN means the rover started over: its previous session is released
+		// (a restarted rover's one is already gone, see handleRoverRestart)
+		// Only OPENs newer than the current session count, and the rover must answer a
+		// PATH_CHALLENGE before a re-opened session moves to another address
+		opened := false
+		if packet.MsgType == ml.MSG_OPEN && (!exists || packet.SeqNum != state.RemoteISN) {
+			keys, provisioned := ms.RoverKeys[roverID]
+			if !provisioned || !packet.VerifyMAC(keys.AuthKey) {
+				ms.Mu.Unlock()
+				ms.Logger.Warnf("ML", "⚠️ OPEN from unregistered rover %d (%s) discarded", roverID, addr)
+				if m := metrics.GlobalMetrics; m != nil {
+					m.RecordAuthFailed()
+				}
+				continue
+			}
+			var open ml.OpenData
+			if err := open.Decode(packet.Payload); err != nil || open.Epoch != keys.Epoch || open.Session <= keys.Session {
+				ms.Mu.Unlock()
+				ms.Logger.Warnf("ML", "⚠️ Stale OPEN from rover %d (%s) discarded", roverID, addr)
+				continue
+			}
+			if exists && state.Epoch == open.Epoch && !sameAddr(addr, state.GetAddr()) {
+				// The rover re-sends the OPEN until the new address is validated
+				ms.validatePath(state, roverID, addr, &packet)
+				ms.Mu.Unlock()
+				continue
+			}
+			sessionKeys, err := keys.ForSession(open.Session)
+			if err != nil {
+				ms.Mu.Unlock()
+				ms.Logger.Warnf("ML", "⚠️ OPEN from rover %d (%s) discarded: %v", roverID, addr, err)
+				continue
+			}
+			keys.Session = open.Session
+			missions := uint8(0)
+			if exists {
+				if state.Epoch == open.Epoch {
+					missions = state.NumberOfMissions // Same process, still running its missions
+				}
+				ms.releaseSession(roverID, state, "re-opened")
+			}
+			if err := ms.NewRoverState(roverID, addr, &packet, sessionKeys, &state); err != nil {
+				ms.Mu.Unlock()
+				ms.Logger.Errorf("ML", "❌ Could not open session with rover %d: %v", roverID, err)
+				continue
+			}
+			state.NumberOfMissions = missions
+			opened = true
+		} else if !exists {
+			ms.Mu.Unlock()
+			ms.Logger.Warnf("ML", "⚠️ %s from rover %d (%s) without session discarded", packet.MsgType, roverID, addr)
+			continue
+		}
+
+		// A packet of an open session from another address means the rover's NAT binding changed
+		if !opened && !sameAddr(addr, state.GetAddr()) && !ms.validatePath(state, roverID, addr, &packet) {
+			ms.Mu.Unlock()
+			continue
 		}
 		ms.Mu.Unlock()
 
@@ -97,16 +164,25 @@ func (ms *MotherShip) receiver(port string) {
 	}
 }
 
-// NewRoverState sets up a new RoverState for a newly connected rover
-func (ms *MotherShip) NewRoverState(roverID uint8, addr *net.UDPAddr, packet *ml.Packet, state **core.RoverState) {
+// NewRoverState sets up a new RoverState for a rover opening a session (packet is its MSG_OPEN)
+// Caller must hold ms.Mu
+func (ms *MotherShip) NewRoverState(roverID uint8, addr *net.UDPAddr, packet *ml.Packet, keys *ml.SessionKeys, state **core.RoverState) error {
+	isn, err := ml.NewISN()
+	if err != nil {
+		return err
+	}
+
 	// Create and initialize RoverState
 	*state = &core.RoverState{
 		Addr:             addr,
-		SeqNum:           0,
+		SeqNum:           isn,
 		ExpectedSeq:      packet.SeqNum,
+		RemoteISN:        packet.SeqNum,
+		Epoch:            keys.Epoch,
 		Buffer:           make(map[uint32]ml.Packet),
-		Window:           pl.NewWindow(),
+		Window:           pl.NewWindow(roverID, keys),
 		NumberOfMissions: 0,
+		MLHealth:         ts.ML_HEALTHY,
 	}
 
 	// Register the new rover state
@@ -129,6 +205,7 @@ func (ms *MotherShip) NewRoverState(roverID uint8, addr *net.UDPAddr, packet *ml
 			Priority3IDs:   []uint16{},
 		},
 	})
+	ms.RoverInfo.SetMissionLink(roverID, ts.ML_HEALTHY) // A reconnecting rover starts over healthy
 
 	// Publish new rover event
 	if ms.APIServer != nil {
@@ -137,23 +214,217 @@ func (ms *MotherShip) NewRoverState(roverID uint8, addr *net.UDPAddr, packet *ml
 			ms.APIServer.PublishUpdate("rover_connected", rover)
 		}
 	}
+	return nil
+}
+
+// validatePath handles a packet of an open session received from a new address
+// The packet must authenticate with the session keys. The rover is then challenged on the
+// new address and only migrated once it echoes the token back in a MSG_PATH_RESPONSE,
+// so a replayed or spoofed packet can't redirect the session
+// Returns whether the packet should still be processed
+// Caller must hold ms.Mu
+func (ms *MotherShip) validatePath(state *core.RoverState, roverID uint8, addr *net.UDPAddr, packet *ml.Packet) bool {
+	if !packet.VerifyMAC(state.Window.Keys().AuthKey) {
+		ms.Logger.Warnf("ML", "⚠️ Unauthenticated %s for rover %d from %s discarded", packet.MsgType, roverID, addr)
+		if m := metrics.GlobalMetrics; m != nil {
+			m.RecordAuthFailed()
+		}
+		return false
+	}
+
+	if packet.MsgType == ml.MSG_PATH_RESPONSE {
+		var response ml.PathData
+		if err := response.Decode(packet.Payload); err != nil || state.PendingAddr == nil ||
+			!sameAddr(addr, state.PendingAddr) || !response.Matches(state.PathChallenge) {
+			ms.Logger.Warnf("ML", "⚠️ Unexpected PATH_RESPONSE from rover %d (%s) discarded", roverID, addr)
+			return false
+		}
+
+		oldAddr := state.GetAddr()
+		state.SetAddr(addr)
+		state.PendingAddr = nil
+		ms.Logger.Infof("ML", "🔀 Rover %d migrated from %s to %s", roverID, oldAddr, addr)
+		if m := metrics.GlobalMetrics; m != nil {
+			m.RecordPathMigration()
+		}
+		if ms.APIServer != nil {
+			ms.APIServer.PublishUpdate("rover_migrated", map[string]any{
+				"id":      roverID,
+				"oldAddr": oldAddr.String(),
+				"newAddr": addr.String(),
+			})
+		}
+		return false
+	}
+
+	// Challenge the new address, unless a challenge to it is still in flight
+	if state.PendingAddr == nil || !sameAddr(addr, state.PendingAddr) ||
+		time.Since(state.ChallengeSentAt) > state.Window.GetRTO() {
+		challenge, err := ml.NewPathData()
+		if err != nil {
+			ms.Logger.Errorf("ML", "❌ Could not create path challenge for rover %d: %v", roverID, err)
+			return true
+		}
+		state.PendingAddr = addr
+		state.PathChallenge = challenge
+		state.ChallengeSentAt = time.Now()
+
+		ms.Logger.Infof("ML", "🔎 Rover %d seen at new address %s, sending PATH_CHALLENGE", roverID, addr)
+		pl.SendPathData(ms.Conn, addr, ml.MSG_PATH_CHALLENGE, challenge, state.Window, 0, ms.Logger.CreateLogCallback("ML"))
+		if m := metrics.GlobalMetrics; m != nil {
+			m.RecordPathChallenge()
+		}
+	}
+	return true
+}
+
+// sameAddr reports whether two UDP addresses are the same IP and port
+func sameAddr(a, b *net.UDPAddr) bool {
+	return a.Port == b.Port && a.IP.Equal(b.IP)
+}
+
+// releaseSession tears down a rover's MissionLink session: pending retransmissions,
+// reorder buffer and mission count are released
+// Caller must hold ms.Mu
+func (ms *MotherShip) releaseSession(roverID uint8, state *core.RoverState, reason string) {
+	if ms.Rovers[roverID] == state {
+		delete(ms.Rovers, roverID)
+	}
+	state.Window.Close()
+
+	state.WindowLock.Lock()
+	state.Buffer = make(map[uint32]ml.Packet)
+	state.WindowLock.Unlock()
+	state.NumberOfMissions = 0
+
+	ms.Logger.Infof("ML", "🔚 Session with rover %d closed (%s)", roverID, reason)
 }
 
 // dispatchPacket forwards the packet to the correct handler based on its type
 // Note: ACK processing (implicit and explicit) is handled automatically by HandleOrderedPacket
 func (ms *MotherShip) dispatchPacket(pkt ml.Packet, state *core.RoverState) {
 	switch pkt.MsgType {
+	case ml.MSG_OPEN:
+		ms.handleOpen(pkt, state, pkt.RoverId)
+	case ml.MSG_CLOSE:
+		ms.handleClose(state, pkt.RoverId)
+	case ml.MSG_HELLO:
+		ms.handleHello(pkt, state, pkt.RoverId)
 	case ml.MSG_REQUEST:
 		ms.handleMissionRequest(pkt, state, pkt.RoverId)
 	case ml.MSG_ACK:
 		// Pure ACK - already processed by HandleOrderedPacket, nothing else to do
+	case ml.MSG_PATH_CHALLENGE, ml.MSG_PATH_RESPONSE:
+		// Path validation is handled by the receiver before dispatching
+	case ml.MSG_PONG:
+		ms.handlePong(pkt, state, pkt.RoverId)
 	case ml.MSG_REPORT:
 		ms.handleReport(pkt, state)
+	case ml.MSG_ERROR:
+		var errData ml.ErrorData
+		if err := errData.Decode(pkt.Payload); err == nil {
+			ms.Logger.Errorf("ML", "❌ Rover %d reported error %d: %s", pkt.RoverId, errData.Code, errData.Message)
+		}
 	default:
 		ms.Logger.Warnf("ML", "⚠️ Unknown packet type: %d", pkt.MsgType)
 	}
 }
 
+// handleRoverRestart releases the session a restarted rover left behind (if any) and gives back
+// to the mission queue its unfinished missions, as the new process starts with an empty queue
+// Their partial reports are archived, and so are the ones its outbox replays later
+func (ms *MotherShip) handleRoverRestart(roverID uint8, epoch uint32) {
+	ms.Mu.Lock()
+	if state, ok := ms.Rovers[roverID]; ok {
+		ms.releaseSession(roverID, state, "rover restarted")
+	}
+	ms.Mu.Unlock()
+
+	requeued := ms.MissionManager.RequeueRoverMissions(roverID, true)
+	ids := ms.requeueMissions(roverID, requeued, "mission_reassigned")
+
+	ms.Logger.Warnf("ML", "🔄 Rover %d restarted (epoch %d), %d unfinished missions requeued: %v", roverID, epoch, len(ids), ids)
+	if ms.APIServer != nil {
+		ms.APIServer.PublishUpdate("rover_restarted", map[string]any{
+			"id":               roverID,
+			"epoch":            epoch,
+			"requeuedMissions": ids,
+		})
+	}
+}
+
+// handleOpen answers a rover's MSG_OPEN with MSG_OPEN_ACK, carrying the mothership's ISN
+func (ms *MotherShip) handleOpen(pkt ml.Packet, state *core.RoverState, roverID uint8) {
+	ms.Logger.Infof("ML", "🔛 Rover %d opened a session (ISN %d)", roverID, pkt.SeqNum)
+
+	pl.CreateAndSendPacket(
+		context.Background(),
+		ms.Conn,
+		state.GetAddr(),
+		0,
+		ml.MSG_OPEN_ACK,
+		&state.SeqNum,
+		pl.CalculateAckNum(pkt), // Implicit ACK for the OPEN
+		[]byte{},
+		state.Window,
+		&state.WindowLock,
+		ms.Logger.CreateLogCallback("ML"),
+	)
+}
+
+// handleClose releases the session of a rover that is shutting down cleanly and requeues
+// its unfinished missions, as it no longer sends the telemetry that would have them reassigned
+// The CLOSE itself was already acknowledged by HandleOrderedPacket
+func (ms *MotherShip) handleClose(state *core.RoverState, roverID uint8) {
+	ms.Mu.Lock()
+	ms.releaseSession(roverID, state, "closed by rover")
+	delete(ms.RoverKeys, roverID) // A new session needs a new ID handshake
+	ms.Mu.Unlock()
+
+	requeued := ms.MissionManager.RequeueRoverMissions(roverID, true)
+	if ids := ms.requeueMissions(roverID, requeued, "mission_reassigned"); len(ids) > 0 {
+		ms.Logger.Warnf("ML", "🔄 %d unfinished missions of rover %d requeued: %v", len(ids), roverID, ids)
+	}
+
+	if rover := ms.RoverInfo.GetRover(roverID); rover != nil {
+		ms.RoverInfo.UpdateRover(roverID, "Disconnected", rover.Battery, 0, rover.Position, rover.MissedTelemetry, rover.QueuedMissions)
+		if ms.APIServer != nil {
+			ms.APIServer.PublishUpdate("rover_disconnected", ms.RoverInfo.GetRover(roverID))
+		}
+	}
+}
+
+// handleHello negotiates the capabilities used with a rover and answers with HELLO_ACK
+func (ms *MotherShip) handleHello(pkt ml.Packet, state *core.RoverState, roverID uint8) {
+	var hello ml.HelloData
+	if err := hello.Decode(pkt.Payload); err != nil {
+		ms.Logger.Errorf("ML", "❌ Invalid HELLO from rover %d: %v", roverID, err)
+		return
+	}
+
+	// Negotiated set is the intersection of both sides' capabilities
+	negotiated := hello.Capabilities & state.Window.LocalCapabilities()
+	state.Window.SetCapabilities(negotiated)
+
+	ms.Logger.Infof("ML", "🤝 Rover %d speaks v%d, negotiated capabilities: %s",
+		roverID, hello.Version, ml.CapabilitiesString(negotiated))
+
+	reply := ml.HelloData{Version: ml.PROTOCOL_VERSION, Capabilities: negotiated}
+	pl.CreateAndSendPacket(
+		context.Background(),
+		ms.Conn,
+		state.GetAddr(),
+		0,
+		ml.MSG_HELLO_ACK,
+		&state.SeqNum,
+		pl.CalculateAckNum(pkt), // Implicit ACK for the HELLO
+		reply.Encode(),
+		state.Window,
+		&state.WindowLock,
+		ms.Logger.CreateLogCallback("ML"),
+	)
+}
+
 // handleMissionRequest processes mission requests from the rover
 func (ms *MotherShip) handleMissionRequest(pkt ml.Packet, state *core.RoverState, roverID uint8) {
 	// Extract number of missions requested from payload (default to 1 if empty)
@@ -169,43 +440,79 @@ func (ms *MotherShip) handleMissionRequest(pkt ml.Packet, state *core.RoverState
 	// Calculate AckNum for the REQUEST packet using protocol helper
 	ackNumForRequest := pl.CalculateAckNum(pkt)
 
-	for i := uint8(0); i < numMissionsRequested; i++ {
+	for missionsSent < numMissionsRequested {
 		// Use ackNum only for the first mission/response as implicit ACK for the REQUEST
 		ackNum := uint32(0)
-		if i == 0 {
+		if missionsSent == 0 {
 			ackNum = ackNumForRequest
 		}
-		select {
-		case missionState := <-ms.MissionQueue:
-
-			ms.assignMissionToRover(missionState, roverID, state, ackNum)
-			missionsSent++
-
-		default:
-			// Empty queue - no more missions available
-			ms.Logger.Warnf("ML", "⚠️ Mission queue empty after sending %d/%d missions", missionsSent, numMissionsRequested)
-			if missionsSent == 0 {
-				ms.sendNoMission(state, ackNum)
-			}
-			return
+		if !ms.reserveMissionSlot(state) {
+			ms.Logger.Warnf("ML", "⚠️ Rover %d already has %d missions (MAX_MISSIONS_PER_ROVER)", roverID, config.MAX_MISSIONS_PER_ROVER)
+			break
 		}
+		queued, ok := ms.Scheduler.Next(ms.RoverView(roverID))
+		if !ok {
+			// Empty queue, or no queued mission suits the rover under the scheduler policy
+			ms.releaseMissionSlot(state)
+			ms.Logger.Warnf("ML", "⚠️ No mission for rover %d after sending %d/%d missions (%s policy)", roverID, missionsSent, numMissionsRequested, ms.Scheduler.Policy().Name())
+			break
+		}
+		// Missions cancelled while queued are left out
+		missionState, ok := ms.MissionManager.AssignMission(queued.ID, roverID)
+		if !ok {
+			ms.releaseMissionSlot(state)
+			continue
+		}
+		ms.assignMissionToRover(missionState, state, ackNum)
+		missionsSent++
 	}
 
+	if missionsSent == 0 {
+		ms.sendNoMission(state, ackNumForRequest)
+		return
+	}
 	ms.Logger.Infof("ML", "✅ Sent %d missions to rover %d", missionsSent, roverID)
 }
 
-// assignMissionToRover assigns a mission to the selected rover and sends it
-func (ms *MotherShip) assignMissionToRover(missionState ml.MissionState, roverID uint8, targetState *core.RoverState, ackNum uint32) {
-	// Mission obtained
-	missionState.IDRover = roverID // Assign the rover to the mission
-	missionState.CreatedAt = time.Now()
-	missionState.LastUpdate = time.Now()
-	missionState.State = "Pending"
-	ms.MissionManager.AddMission(&missionState)
+// requeueMissions hands back to the scheduler missions detached from a rover,
+// publishing eventType for each one. Returns the IDs of the missions requeued
+func (ms *MotherShip) requeueMissions(roverID uint8, missions []ml.MissionState, eventType string) []uint16 {
+	ids := make([]uint16, 0, len(missions))
+	for i := range missions {
+		if err := ms.Scheduler.Push(missions[i]); err != nil {
+			ms.Logger.Errorf("ML", "❌ %v, mission %d of rover %d not requeued", err, missions[i].ID, roverID)
+			continue
+		}
+		ids = append(ids, missions[i].ID)
+		ms.publishMissionEvents(&missions[i], eventType)
+	}
+	return ids
+}
+
+// reserveMissionSlot counts one more mission for the rover, unless it already has
+// MAX_MISSIONS_PER_ROVER (0 = no limit)
+func (ms *MotherShip) reserveMissionSlot(state *core.RoverState) bool {
+	ms.Mu.Lock()
+	defer ms.Mu.Unlock()
+	if config.MAX_MISSIONS_PER_ROVER > 0 && state.NumberOfMissions >= config.MAX_MISSIONS_PER_ROVER {
+		return false
+	}
+	state.NumberOfMissions++
+	return true
+}
 
-	// Increment rover's mission count
-	targetState.NumberOfMissions++
+// releaseMissionSlot gives back a slot reserved for a mission that was not sent
+func (ms *MotherShip) releaseMissionSlot(state *core.RoverState) {
+	ms.Mu.Lock()
+	defer ms.Mu.Unlock()
+	if state.NumberOfMissions > 0 {
+		state.NumberOfMissions--
+	}
+}
 
+// assignMissionToRover sends a mission already assigned to the rover (see MissionManager.AssignMission)
+// The rover's mission count was already increased by reserveMissionSlot
+func (ms *MotherShip) assignMissionToRover(missionState ml.MissionState, targetState *core.RoverState, ackNum uint32) {
 	// Publish mission created event
 	ms.publishMissionEvents(&missionState, "mission_created")
 
@@ -222,8 +529,9 @@ func (ms *MotherShip) assignMissionToRover(missionState ml.MissionState, roverID
 	payload := missionData.Encode()
 
 	pl.CreateAndSendPacket(
+		context.Background(),
 		ms.Conn,
-		targetState.Addr,
+		targetState.GetAddr(),
 		0,
 		ml.MSG_MISSION,
 		&targetState.SeqNum,
@@ -234,10 +542,8 @@ func (ms *MotherShip) assignMissionToRover(missionState ml.MissionState, roverID
 		ms.Logger.CreateLogCallback("ML"),
 	)
 
-	ms.Logger.Infof("ML", "✅ Mission %d sent to %s", missionState.ID, targetState.Addr)
+	ms.Logger.Infof("ML", "✅ Mission %d sent to %s", missionState.ID, targetState.GetAddr())
 
-	// Change state to "Moving to" after sending the mission
-	ms.MissionManager.UpdateMissionState(missionState.ID, "Pending")
 	ms.publishMissionEvents(&missionState, "mission_update")
 }
 
@@ -250,11 +556,12 @@ func (ms *MotherShip) publishMissionEvents(mission *ml.MissionState, eventType s
 
 // sendNoMission sends a NO_MISSION packet to a rover
 func (ms *MotherShip) sendNoMission(state *core.RoverState, ackNum uint32) {
-	ms.Logger.Warnf("ML", "⚠️ Mission queue empty or rovers overloaded. Sending NO_MISSION to %s", state.Addr)
+	ms.Logger.Warnf("ML", "⚠️ Mission queue empty or rovers overloaded. Sending NO_MISSION to %s", state.GetAddr())
 
 	pl.CreateAndSendPacket(
+		context.Background(),
 		ms.Conn,
-		state.Addr,
+		state.GetAddr(),
 		0,
 		ml.MSG_NO_MISSION,
 		&state.SeqNum,
@@ -269,7 +576,7 @@ func (ms *MotherShip) sendNoMission(state *core.RoverState, ackNum uint32) {
 
 // handleReport processes reports from rovers
 func (ms *MotherShip) handleReport(p ml.Packet, state *core.RoverState) {
-	ms.Logger.Debugf("ML", "📊 Report received from %s", state.Addr)
+	ms.Logger.Debugf("ML", "📊 Report received from %s", state.GetAddr())
 	if len(p.Payload) < ml.REPORT_HEADER_SIZE {
 		ms.Logger.Errorf("ML", "❌ Empty or incomplete payload")
 		return
@@ -280,12 +587,43 @@ func (ms *MotherShip) handleReport(p ml.Packet, state *core.RoverState) {
 		ms.Logger.Errorf("ML", "❌ Error deserializing report: %v", err)
 		return
 	}
+	if report.Header.Aborted {
+		ms.Logger.Warnf("ML", "🛑 Rover %d aborted mission %d", p.RoverId, report.Header.MissionID)
+	} else if _, err := report.DecodeTyped(); err != nil {
+		ms.Logger.Errorf("ML", "❌ Malformed report payload discarded: %v", err)
+		return
+	}
 
-	ms.Logger.Infof("ML", "✅ Report received: TaskType=%d, MissionID=%d, IsLast=%v, PayloadLen=%d",
-		report.Header.TaskType, report.Header.MissionID, report.Header.IsLastReport, len(report.Payload))
+	ms.Logger.Infof("ML", "✅ Report received: TaskType=%d, MissionID=%d, Seq=%d, IsLast=%v, PayloadLen=%d",
+		report.Header.TaskType, report.Header.MissionID, report.Header.Seq, report.Header.IsLastReport, len(report.Payload))
+
+	// Update mission state in Mission Manager. Replays from an earlier session may refer to a
+	// mission that was requeued since
+	added, missing, err := ml.UpdateMission(ms.MissionManager, p.RoverId, report)
+	if errors.Is(err, ml.ErrMissionReassigned) {
+		if added {
+			ms.Logger.Infof("ML", "🗄️ Late report %d of mission %d archived with rover %d's earlier attempt",
+				report.Header.Seq, report.Header.MissionID, p.RoverId)
+			if mission := ms.MissionManager.GetMission(report.Header.MissionID); mission != nil {
+				ms.publishMissionEvents(mission, "mission_update")
+			}
+		}
+		return
+	}
+	if err != nil {
+		ms.Logger.Warnf("ML", "⚠️ Report for mission %d not assigned to rover %d discarded: %v", report.Header.MissionID, p.RoverId, err)
+		return
+	}
+	if !added {
+		ms.Logger.Infof("ML", "♻️ Duplicate report %d of mission %d ignored", report.Header.Seq, report.Header.MissionID)
+		return
+	}
 
 	if report.Header.IsLastReport {
 		ms.Logger.Infof("ML", "🏁 Last report received for mission %d", report.Header.MissionID)
+		if len(missing) > 0 {
+			ms.Logger.Warnf("ML", "🕳️ Mission %d still missing reports %v", report.Header.MissionID, missing)
+		}
 		ms.Mu.Lock()
 		if state.NumberOfMissions > 0 {
 			state.NumberOfMissions--
@@ -294,9 +632,6 @@ func (ms *MotherShip) handleReport(p ml.Packet, state *core.RoverState) {
 		ms.MissionManager.PrintMissions()
 	}
 
-	// Update mission state in Mission Manager
-	ml.UpdateMission(ms.MissionManager, report)
-
 	// Publish mission update event
 	if ms.APIServer != nil {
 		mission := ms.MissionManager.GetMission(report.Header.MissionID)
@@ -65,7 +65,10 @@ func (ms *MotherShip) handleTelemetryConnection(conn net.Conn) {
 
 		// Received something → handle telemetry
 		var telemetry ts.TelemetryPacket
-		telemetry.Decode(buf[:n])
+		if err := telemetry.Decode(buf[:n]); err != nil {
+			ms.Logger.Warnf("TS", "⚠️ Invalid telemetry discarded: %v", err)
+			continue
+		}
 
 		roverID = telemetry.RoverID
 		missed = 0 // reset
@@ -85,20 +88,46 @@ func (ms *MotherShip) handleTelemetryConnection(conn net.Conn) {
 func (ms *MotherShip) handleMissedTelemetry(roverID uint8, missed, maxMissed int) {
 	// Get current rover info
 	rover := ms.RoverInfo.GetRover(roverID)
-	if rover == nil {
-		return
+	if rover == nil || rover.State == "Disconnected" {
+		return // Rovers that closed their session aren't expected to send telemetry (handleClose requeued their missions)
 	}
 
 	// Check if rover should be declared inoperational
 	if missed >= maxMissed {
 		ms.RoverInfo.UpdateRover(roverID, "Inoperational", rover.Battery, rover.Speed, rover.Position, missed, rover.QueuedMissions)
 		ms.Logger.Errorf("TS", "Rover %d declared inoperational due to lack of telemetry", roverID)
+		ms.reassignRoverMissions(roverID)
 	} else {
 		// Partial update without changing the rest
 		ms.RoverInfo.UpdateRover(roverID, rover.State, rover.Battery, rover.Speed, rover.Position, missed, rover.QueuedMissions)
 	}
 }
 
+// reassignRoverMissions gives the unfinished missions of a rover declared inoperational
+// to the other rovers, with their priority and the reports received so far
+func (ms *MotherShip) reassignRoverMissions(roverID uint8) {
+	requeued := ms.MissionManager.RequeueRoverMissions(roverID, true)
+	if len(requeued) == 0 {
+		return
+	}
+
+	ms.Mu.Lock()
+	if state := ms.Rovers[roverID]; state != nil {
+		state.NumberOfMissions = 0
+	}
+	ms.Mu.Unlock()
+
+	// Only the telemetry may be down: if the MissionLink is alive, the rover drops the missions
+	// (its reports for them are discarded anyway, as they are no longer assigned to it)
+	for _, mission := range requeued {
+		mission.IDRover = roverID
+		ms.sendCancel(mission)
+	}
+
+	ids := ms.requeueMissions(roverID, requeued, "mission_reassigned")
+	ms.Logger.Warnf("TS", "🔄 %d unfinished missions of rover %d requeued: %v", len(ids), roverID, ids)
+}
+
 // updateRoverTelemetry updates the rover information based on received telemetry
 func (ms *MotherShip) updateRoverTelemetry(t *ts.TelemetryPacket) {
 	stateText := "Idle"
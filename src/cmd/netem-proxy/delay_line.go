@@ -0,0 +1,142 @@
+package main
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// delayLine holds payloads until their due time, then sends them in due order
+// (payloads due at the same time keep the order they were pushed in)
+type delayLine struct {
+	send func([]byte)
+
+	mu       sync.Mutex
+	queue    pendingQueue
+	pushed   uint64
+	draining bool
+
+	wake    chan struct{} // Signals a new payload or drain request
+	done    chan struct{} // Closed by stop: pending payloads are discarded
+	drained chan struct{} // Closed once the line stopped sending
+}
+
+// newDelayLine starts a delay line sending with send
+func newDelayLine(send func([]byte)) *delayLine {
+	l := &delayLine{
+		send:    send,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		drained: make(chan struct{}),
+	}
+	go l.run()
+	return l
+}
+
+// push schedules a payload for due
+func (l *delayLine) push(data []byte, due time.Time) {
+	l.mu.Lock()
+	l.pushed++
+	heap.Push(&l.queue, &pending{data: data, due: due, order: l.pushed})
+	l.mu.Unlock()
+	l.signal()
+}
+
+// drain blocks until every pending payload was sent, then stops the line
+func (l *delayLine) drain() {
+	l.mu.Lock()
+	l.draining = true
+	l.mu.Unlock()
+	l.signal()
+	<-l.drained
+}
+
+// stop discards the pending payloads and stops the line
+func (l *delayLine) stop() {
+	select {
+	case <-l.done:
+	default:
+		close(l.done)
+	}
+	<-l.drained
+}
+
+// signal wakes the sending loop up
+func (l *delayLine) signal() {
+	select {
+	case l.wake <- struct{}{}:
+	default:
+		// A wake-up is already pending
+	}
+}
+
+// run sends every payload once due
+func (l *delayLine) run() {
+	defer close(l.drained)
+	for {
+		l.mu.Lock()
+		if len(l.queue) == 0 {
+			draining := l.draining
+			l.mu.Unlock()
+			if draining {
+				return
+			}
+			select {
+			case <-l.wake:
+			case <-l.done:
+				return
+			}
+			continue
+		}
+		wait := time.Until(l.queue[0].due)
+		if wait <= 0 {
+			p := heap.Pop(&l.queue).(*pending)
+			l.mu.Unlock()
+			l.send(p.data)
+			continue
+		}
+		l.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-l.wake:
+			timer.Stop()
+		case <-l.done:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// pending is a payload waiting in a delay line
+type pending struct {
+	data  []byte
+	due   time.Time
+	order uint64
+}
+
+// pendingQueue is a min-heap of payloads ordered by due time
+type pendingQueue []*pending
+
+func (q pendingQueue) Len() int { return len(q) }
+func (q pendingQueue) Less(i, j int) bool {
+	if q[i].due.Equal(q[j].due) {
+		return q[i].order < q[j].order
+	}
+	return q[i].due.Before(q[j].due)
+}
+func (q pendingQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *pendingQueue) Push(x any) {
+	*q = append(*q, x.(*pending))
+}
+
+func (q *pendingQueue) Pop() any {
+	old := *q
+	n := len(old)
+	p := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return p
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"src/config"
+	pl "src/utils/packetsLogic"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// netem-proxy sits between the rovers and the mothership and impairs their traffic, to
+// reproduce the experiments of the CORE topologies on any machine.
+// It listens on the ports of config.json and forwards to the mothership (-ms-ip) on the same
+// ports shifted by -upstream-offset, so on a single machine the mothership is started with
+// -port-offset and the rovers connect to the proxy as if it was the mothership:
+//
+//	mothership -port-offset=10000
+//	netem-proxy -preset=realista
+//	rover
+//
+// MissionLink datagrams get the whole impairment; TCP connections (ID handshake and
+// TelemetryLink) only the latency it causes (see tcpImpairment).
+func main() {
+	presetName := flag.String("preset", "realista", "Impairment preset: "+presetNames())
+	listenHost := flag.String("listen", "0.0.0.0", "Address the proxy listens on")
+	upstreamOffset := flag.Int("upstream-offset", 10000, "Port offset of the mothership behind the proxy (its -port-offset)")
+	seed := flag.Int64("seed", 1, "Seed of the random impairments (same seed, same losses)")
+	loss := flag.Float64("loss", 0, "Datagram loss in percent (overrides the preset)")
+	dup := flag.Float64("dup", 0, "Datagram duplication in percent (overrides the preset)")
+	corrupt := flag.Float64("corrupt", 0, "Datagram corruption in percent, one flipped bit (overrides the preset)")
+	reorder := flag.Float64("reorder", 0, "Datagram reordering in percent (overrides the preset)")
+	reorderDelay := flag.Duration("reorder-delay", 50*time.Millisecond, "Extra delay of reordered datagrams")
+	delay := flag.Duration("delay", 0, "One-way latency (overrides the preset)")
+	jitter := flag.Duration("jitter", 0, "Random extra latency, up to this value (overrides the preset)")
+
+	config.InitConfig(false, false) // Parses the flags above too; -ms-ip is the mothership behind the proxy
+
+	p, ok := lookupPreset(*presetName)
+	if !ok {
+		fmt.Printf("❌ Unknown preset %q (available: %s)\n", *presetName, presetNames())
+		os.Exit(2)
+	}
+	imp := p.impairment
+	imp.ReorderDelay = *reorderDelay
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "loss":
+			imp.Loss = *loss / 100
+		case "dup":
+			imp.Duplicate = *dup / 100
+		case "corrupt":
+			imp.Corrupt = *corrupt / 100
+		case "reorder":
+			imp.Reorder = *reorder / 100
+		case "delay":
+			imp.Delay = *delay
+		case "jitter":
+			imp.Jitter = *jitter
+		}
+	})
+
+	fmt.Printf("🌪️ netem-proxy: preset %s (%s)\n", *presetName, p.description)
+	fmt.Printf("   delay %v, jitter %v, loss %.1f%%, dup %.1f%%, corrupt %.1f%%, reorder %.1f%% (+%v)\n",
+		imp.Delay, imp.Jitter, imp.Loss*100, imp.Duplicate*100, imp.Corrupt*100, imp.Reorder*100, imp.ReorderDelay)
+
+	// Each direction of each link has its own impairer, seeded from -seed
+	impairers := make(map[string]*pl.Impairer)
+	newImpairer := func(name string, imp pl.Impairment) *pl.Impairer {
+		impairers[name] = pl.NewImpairer(imp, *seed+int64(len(impairers)))
+		return impairers[name]
+	}
+	listen := func(port string) string { return net.JoinHostPort(*listenHost, port) }
+	upstream := func(port string) string {
+		n, _ := strconv.Atoi(port)
+		return net.JoinHostPort(config.GlobalConfig.MotherIP, strconv.Itoa(n+*upstreamOffset))
+	}
+
+	mission, err := newUDPRelay("MissionLink", listen(config.UDP_COMM_PORT), upstream(config.UDP_COMM_PORT),
+		newImpairer("MissionLink ↑", imp), newImpairer("MissionLink ↓", imp))
+	if err != nil {
+		fmt.Println("❌ Error starting MissionLink relay:", err)
+		os.Exit(1)
+	}
+	id, err := newTCPRelay("ID", listen(config.TCP_ID_PORT), upstream(config.TCP_ID_PORT),
+		newImpairer("ID ↑", tcpImpairment(imp)), newImpairer("ID ↓", tcpImpairment(imp)))
+	if err != nil {
+		fmt.Println("❌ Error starting ID relay:", err)
+		os.Exit(1)
+	}
+	telemetry, err := newTCPRelay("TelemetryLink", listen(config.TCP_TELEMETRY_PORT), upstream(config.TCP_TELEMETRY_PORT),
+		newImpairer("TelemetryLink ↑", tcpImpairment(imp)), newImpairer("TelemetryLink ↓", tcpImpairment(imp)))
+	if err != nil {
+		fmt.Println("❌ Error starting TelemetryLink relay:", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("📡 MissionLink  udp %s → %s\n", listen(config.UDP_COMM_PORT), upstream(config.UDP_COMM_PORT))
+	fmt.Printf("🪪 ID           tcp %s → %s\n", listen(config.TCP_ID_PORT), upstream(config.TCP_ID_PORT))
+	fmt.Printf("📈 Telemetry    tcp %s → %s\n", listen(config.TCP_TELEMETRY_PORT), upstream(config.TCP_TELEMETRY_PORT))
+
+	// Print what was done to the traffic on shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		printStats(impairers)
+		os.Exit(0)
+	}()
+
+	go id.serve()
+	go telemetry.serve()
+	mission.serve()
+}
+
+// printStats prints the datagrams handled by every impairer
+func printStats(impairers map[string]*pl.Impairer) {
+	fmt.Println("\n=== netem-proxy statistics ===")
+	for _, name := range []string{"MissionLink ↑", "MissionLink ↓", "ID ↑", "ID ↓", "TelemetryLink ↑", "TelemetryLink ↓"} {
+		s := impairers[name].Stats()
+		fmt.Printf("%-16s sent %d, dropped %d, duplicated %d, corrupted %d, reordered %d\n",
+			name, s.Sent, s.Dropped, s.Duplicated, s.Corrupted, s.Reordered)
+	}
+}
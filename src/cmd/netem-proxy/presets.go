@@ -0,0 +1,63 @@
+package main
+
+import (
+	"sort"
+	pl "src/utils/packetsLogic"
+	"strings"
+	"time"
+)
+
+// preset is a set of impairments mirroring one of the CORE topologies of the report
+// The topologies impair each link separately; a preset is the impairment of a whole
+// rover → switch → router → mothership path (delays add up, losses compound)
+type preset struct {
+	description string
+	impairment  pl.Impairment
+}
+
+// presets by name
+// TopologiaRealista.xml and TopologiaPéssima.xml share the same link table: "realista" is
+// the average rover path and "pessima" takes the worst value of every parameter over all paths
+var presets = map[string]preset{
+	"none": {
+		description: "no impairment",
+	},
+	"ideal": {
+		description: "TopologiaIdeal.xml: 3 lossless links of 100 ms",
+		impairment: pl.Impairment{
+			Delay: 300 * time.Millisecond,
+		},
+	},
+	"realista": {
+		description: "TopologiaRealista.xml: average rover path (480 ms, 14% loss, 5% duplicates)",
+		impairment: pl.Impairment{
+			Delay:     480 * time.Millisecond,
+			Loss:      0.14,
+			Duplicate: 0.05,
+		},
+	},
+	"pessima": {
+		description: "TopologiaPéssima.xml: worst rover path (500 ms, 17% loss, 7% duplicates)",
+		impairment: pl.Impairment{
+			Delay:     500 * time.Millisecond,
+			Loss:      0.17,
+			Duplicate: 0.07,
+		},
+	},
+}
+
+// lookupPreset finds a preset by name, accents and case aside ("Péssima" is "pessima")
+func lookupPreset(name string) (preset, bool) {
+	p, ok := presets[strings.ReplaceAll(strings.ToLower(name), "é", "e")]
+	return p, ok
+}
+
+// presetNames lists the presets, for usage messages
+func presetNames() string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	pl "src/utils/packetsLogic"
+	"time"
+)
+
+// TCP recovers losses itself, so a TCP stream only perceives an impairment as latency:
+// every lost (or corrupted) segment arrives one retransmission timeout later, and
+// duplicates and reordering are hidden by the receiver
+const (
+	tcpRetransmitTimeout = 200 * time.Millisecond // Linux minimum RTO
+	tcpMaxAttempts       = 6                      // A segment is never held back more than this many RTOs
+)
+
+// tcpImpairment is how a TCP stream perceives a datagram impairment
+func tcpImpairment(imp pl.Impairment) pl.Impairment {
+	return pl.Impairment{
+		Loss:   1 - (1-imp.Loss)*(1-imp.Corrupt),
+		Delay:  imp.Delay,
+		Jitter: imp.Jitter,
+	}
+}
+
+// tcpRelay forwards TCP connections to the target, delaying both directions
+type tcpRelay struct {
+	name     string
+	listener net.Listener
+	target   string
+	up       *pl.Impairer // Client → target (see tcpImpairment)
+	down     *pl.Impairer // Target → client
+}
+
+// newTCPRelay listens on listen and relays to target
+func newTCPRelay(name, listen, target string, up, down *pl.Impairer) (*tcpRelay, error) {
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		return nil, err
+	}
+	return &tcpRelay{name: name, listener: listener, target: target, up: up, down: down}, nil
+}
+
+// serve accepts connections until the listener fails
+func (r *tcpRelay) serve() {
+	for {
+		client, err := r.listener.Accept()
+		if err != nil {
+			fmt.Printf("❌ %s: error accepting connection: %v\n", r.name, err)
+			return
+		}
+		go r.relay(client)
+	}
+}
+
+// relay pipes a client connection to a new connection to the target
+func (r *tcpRelay) relay(client net.Conn) {
+	defer client.Close()
+
+	server, err := net.Dial("tcp", r.target)
+	if err != nil {
+		fmt.Printf("❌ %s: could not reach %s for %s: %v\n", r.name, r.target, client.RemoteAddr(), err)
+		return
+	}
+	defer server.Close()
+
+	done := make(chan struct{}, 2)
+	go pipe(client, server, r.up, done)
+	go pipe(server, client, r.down, done)
+	<-done // Either side closed: the deferred closes end the other direction
+}
+
+// pipe copies src to dst, each chunk reaching dst after its impaired transit time
+// Chunks never overtake each other, as in a TCP stream
+func pipe(src, dst net.Conn, impairer *pl.Impairer, done chan<- struct{}) {
+	defer func() { done <- struct{}{} }()
+
+	line := newDelayLine(func(b []byte) { dst.Write(b) })
+	var last time.Time
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			due := time.Now().Add(transitTime(impairer, buf[:n]))
+			if due.Before(last) {
+				due = last
+			}
+			last = due
+			line.push(append([]byte(nil), buf[:n]...), due)
+		}
+		if err != nil {
+			break
+		}
+	}
+	line.drain()
+}
+
+// transitTime returns how long a chunk takes to cross the link, retransmissions included
+func transitTime(impairer *pl.Impairer, data []byte) time.Duration {
+	var penalty time.Duration
+	for attempt := 0; attempt < tcpMaxAttempts; attempt++ {
+		if deliveries := impairer.Impair(data); len(deliveries) > 0 {
+			return penalty + deliveries[0].Delay
+		}
+		penalty += tcpRetransmitTimeout
+	}
+	return penalty
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	pl "src/utils/packetsLogic"
+	"sync"
+	"time"
+)
+
+// udpSessionIdle is how long a client may stay silent before its session is released
+// (MissionLink keepalives keep live rovers well below it)
+const udpSessionIdle = 2 * time.Minute
+
+// udpRelay forwards the datagrams of every client to the target through its own upstream
+// socket, so the target tells the clients apart, impairing both directions
+type udpRelay struct {
+	name     string
+	listener *net.UDPConn
+	target   *net.UDPAddr
+	up       *pl.Impairer // Client → target
+	down     *pl.Impairer // Target → client
+
+	mu       sync.Mutex
+	sessions map[string]*udpSession
+}
+
+// udpSession is the upstream side of a client
+type udpSession struct {
+	client   *net.UDPAddr
+	upstream *net.UDPConn
+	toTarget *delayLine
+	toClient *delayLine
+	lastSeen time.Time // Guarded by udpRelay.mu
+}
+
+// newUDPRelay listens on listen and relays to target
+func newUDPRelay(name, listen, target string, up, down *pl.Impairer) (*udpRelay, error) {
+	listenAddr, err := net.ResolveUDPAddr("udp", listen)
+	if err != nil {
+		return nil, err
+	}
+	targetAddr, err := net.ResolveUDPAddr("udp", target)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.ListenUDP("udp", listenAddr)
+	if err != nil {
+		return nil, err
+	}
+	return &udpRelay{
+		name:     name,
+		listener: listener,
+		target:   targetAddr,
+		up:       up,
+		down:     down,
+		sessions: make(map[string]*udpSession),
+	}, nil
+}
+
+// serve relays client datagrams until the listener fails
+func (r *udpRelay) serve() {
+	go r.expireSessions()
+
+	buf := make([]byte, 65535)
+	for {
+		n, client, err := r.listener.ReadFromUDP(buf)
+		if err != nil {
+			fmt.Printf("❌ %s: error reading datagram: %v\n", r.name, err)
+			return
+		}
+		session, err := r.session(client)
+		if err != nil {
+			fmt.Printf("❌ %s: no upstream socket for %s: %v\n", r.name, client, err)
+			continue
+		}
+		impair(r.up, buf[:n], session.toTarget)
+	}
+}
+
+// session returns the session of a client, opening it on its first datagram
+func (r *udpRelay) session(client *net.UDPAddr) (*udpSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s, ok := r.sessions[client.String()]; ok {
+		s.lastSeen = time.Now()
+		return s, nil
+	}
+
+	upstream, err := net.DialUDP("udp", nil, r.target)
+	if err != nil {
+		return nil, err
+	}
+	s := &udpSession{
+		client:   client,
+		upstream: upstream,
+		lastSeen: time.Now(),
+	}
+	s.toTarget = newDelayLine(func(b []byte) { upstream.Write(b) })
+	s.toClient = newDelayLine(func(b []byte) { r.listener.WriteToUDP(b, client) })
+	r.sessions[client.String()] = s
+	go r.serveUpstream(s)
+
+	fmt.Printf("🔗 %s: %s ↔ %s via %s\n", r.name, client, r.target, upstream.LocalAddr())
+	return s, nil
+}
+
+// serveUpstream relays the target's answers to a client until its session is released
+func (r *udpRelay) serveUpstream(s *udpSession) {
+	buf := make([]byte, 65535)
+	for {
+		n, err := s.upstream.Read(buf)
+		if err != nil {
+			return
+		}
+		impair(r.down, buf[:n], s.toClient)
+	}
+}
+
+// expireSessions periodically releases the sessions of silent clients
+func (r *udpRelay) expireSessions() {
+	ticker := time.NewTicker(udpSessionIdle / 4)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		r.mu.Lock()
+		var idle []*udpSession
+		for key, s := range r.sessions {
+			if time.Since(s.lastSeen) > udpSessionIdle {
+				idle = append(idle, s)
+				delete(r.sessions, key)
+			}
+		}
+		r.mu.Unlock()
+
+		for _, s := range idle {
+			s.upstream.Close()
+			s.toTarget.stop()
+			s.toClient.stop()
+			fmt.Printf("💤 %s: session of %s released\n", r.name, s.client)
+		}
+	}
+}
+
+// impair schedules the copies of a datagram that survive the impairment
+func impair(impairer *pl.Impairer, data []byte, line *delayLine) {
+	now := time.Now()
+	for _, d := range impairer.Impair(data) {
+		line.push(d.Data, now.Add(d.Delay))
+	}
+}
@@ -35,11 +35,25 @@ func main() {
 		panic("Failed to initialize Rover System")
 	}
 
-	// Setup graceful shutdown to print metrics
+	// Create Rover instance
+	rover := Rover{RoverSystem: roverSys}
+
+	// Reports the mothership never acknowledges are kept in the outbox (see outbox.go)
+	rover.ML.Window.SetGiveUpHandler(rover.messageGivenUp)
+
+	// Start receiving, open the session and negotiate protocol capabilities before anything else
+	go rover.receiver()
+	if err := rover.open(); err != nil {
+		rover.Logger.Errorf("MissionLink", "Session establishment failed: %v", err)
+		os.Exit(1)
+	}
+
+	// Setup graceful shutdown: close the session and print metrics
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-sigChan
+		rover.close()
 		if config.IsTestMode() && metrics.GlobalMetrics != nil {
 			// Include rover ID in filename
 			filename := fmt.Sprintf("../metrics/rover_%d_metrics.json", roverSys.ID)
@@ -48,11 +62,13 @@ func main() {
 		os.Exit(0)
 	}()
 
-	// Create Rover instance
-	rover := Rover{RoverSystem: roverSys}
+	if err := rover.negotiate(); err != nil {
+		rover.Logger.Errorf("MissionLink", "Capability negotiation failed: %v", err)
+		os.Exit(1)
+	}
 
 	// Start Rover services
-	go rover.receiver()
+	go rover.recoverLink() // Replays the outbox and re-opens the session when the link is lost
 	go rover.telemetrySender(mothershipTelemetry)
 	go rover.manageMissions()
 	go rover.batteryMonitor() // Monitor battery level continuously
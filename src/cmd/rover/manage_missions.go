@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"src/config"
 	"src/internal/core"
 	"src/internal/devices"
@@ -9,12 +11,23 @@ import (
 )
 
 // ExecuteMission processes a single mission: moves to location, performs task, and sends reports
+// A MSG_CANCEL interrupts it wherever it is, ending with an aborted report
 func (rover *Rover) ExecuteMission(mission ml.MissionData) {
 	rover.IncrementActiveMission()
 	defer rover.DecrementActiveMission()
 
 	rover.Logger.Infof("Mission", "Mission %d received: TaskType=%d", mission.MsgID, mission.TaskType)
 
+	var reportSeq uint16 // Number of the next report of this mission
+
+	ctx, started := rover.startMission(mission.MsgID)
+	if !started {
+		rover.Logger.Warnf("Mission", "Mission %d cancelled before starting", mission.MsgID)
+		rover.sendAbortedReport(mission, reportSeq)
+		return
+	}
+	defer rover.finishMission()
+
 	// For image capture tasks, load the image
 	if mission.TaskType == ml.TASK_IMAGE_CAPTURE {
 		imagePath := "../assets/image.jpg" // Image path in assets folder
@@ -28,12 +41,16 @@ func (rover *Rover) ExecuteMission(mission ml.MissionData) {
 	// Move to mission location
 	rover.Logger.Infof("Movement", "Moving to coordinates (%.4f, %.4f)", mission.Coordinate.Latitude, mission.Coordinate.Longitude)
 	if err := core.MoveTo(
+		ctx,
 		&rover.RoverBase.CurrentPos,
 		mission.Coordinate,
 		rover.Devices.GPS,
 		rover.Devices.Battery,
 		rover.Logger,
-	); err != nil {
+	); errors.Is(err, context.Canceled) {
+		rover.sendAbortedReport(mission, reportSeq)
+		return
+	} else if err != nil {
 		rover.Logger.Errorf("Movement", "Error moving: %v", err)
 		return
 	}
@@ -57,11 +74,14 @@ func (rover *Rover) ExecuteMission(mission ml.MissionData) {
 					rover.Logger.Infof("Battery", "Battery recharged. Resuming mission %d", mission.MsgID)
 				}
 			case <-deadline.C:
-				rover.sendReport(mission, true)
+				rover.sendReport(mission, true, &reportSeq)
 				core.ConsumeBattery(rover.Devices.Battery, config.TASK_BATTERY_RATE)
 				return
 			case <-ticker.C:
-				rover.sendReport(mission, false)
+				rover.sendReport(mission, false, &reportSeq)
+			case <-ctx.Done():
+				rover.sendAbortedReport(mission, reportSeq)
+				return
 			}
 		}
 	} else {
@@ -73,12 +93,72 @@ func (rover *Rover) ExecuteMission(mission ml.MissionData) {
 					rover.Logger.Infof("Battery", "Battery recharged. Resuming mission %d", mission.MsgID)
 				}
 			case <-deadline.C:
-				rover.sendReport(mission, true)
+				rover.sendReport(mission, true, &reportSeq)
 				core.ConsumeBattery(rover.Devices.Battery, config.TASK_BATTERY_RATE)
 				return
+			case <-ctx.Done():
+				rover.sendAbortedReport(mission, reportSeq)
+				return
+			}
+		}
+	}
+}
+
+// startMission makes the mission dequeued for execution cancellable
+// Returns false if it was cancelled since it left the queue
+func (rover *Rover) startMission(missionID uint16) (context.Context, bool) {
+	queue := rover.ML.MissionQueue
+	queue.Mu.Lock()
+	defer queue.Mu.Unlock()
+
+	if queue.Running != missionID {
+		return nil, false
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	queue.CancelRunning = cancel
+	return ctx, true
+}
+
+// finishMission releases the running mission
+func (rover *Rover) finishMission() {
+	queue := rover.ML.MissionQueue
+	queue.Mu.Lock()
+	defer queue.Mu.Unlock()
+
+	if queue.CancelRunning != nil {
+		queue.CancelRunning()
+	}
+	queue.Running = 0
+	queue.CancelRunning = nil
+}
+
+// cancelMission drops a mission recalled by the mothership from the queue, or interrupts it
+// if it already left the queue
+// Returns the mission if it was still queued (nobody else will report its abort), and
+// whether the rover had the mission at all
+func (rover *Rover) cancelMission(missionID uint16) (queued ml.MissionData, wasQueued bool, found bool) {
+	queue := rover.ML.MissionQueue
+	queue.Mu.Lock()
+	defer queue.Mu.Unlock()
+
+	for _, missions := range []*[]ml.MissionData{&queue.Priority1, &queue.Priority2, &queue.Priority3} {
+		for i, mission := range *missions {
+			if mission.MsgID == missionID {
+				*missions = append((*missions)[:i:i], (*missions)[i+1:]...)
+				return mission, true, true
 			}
 		}
 	}
+
+	if queue.Running == missionID {
+		if queue.CancelRunning != nil {
+			queue.CancelRunning()
+		} else {
+			queue.Running = 0 // Not started yet: startMission will refuse it
+		}
+		return ml.MissionData{}, false, true
+	}
+	return ml.MissionData{}, false, false
 }
 
 // manageMissions handles mission requests and execution flow with priority queue
@@ -243,32 +323,21 @@ func (rover *Rover) isQueueEmpty() bool {
 }
 
 // dequeueNextMission gets the next mission from highest priority queue
+// The mission becomes the running one, so a MSG_CANCEL still reaches it before it starts
 func (rover *Rover) dequeueNextMission() (ml.MissionData, bool) {
-	rover.ML.MissionQueue.Mu.Lock()
-	defer rover.ML.MissionQueue.Mu.Unlock()
-
-	// Check Priority 1 first
-	if len(rover.ML.MissionQueue.Priority1) > 0 {
-		mission := rover.ML.MissionQueue.Priority1[0]
-		rover.ML.MissionQueue.Priority1 = rover.ML.MissionQueue.Priority1[1:]
-		rover.Logger.Infof("Mission", "Dequeued mission %d from Priority 1 queue", mission.MsgID)
-		return mission, true
-	}
-
-	// Check Priority 2
-	if len(rover.ML.MissionQueue.Priority2) > 0 {
-		mission := rover.ML.MissionQueue.Priority2[0]
-		rover.ML.MissionQueue.Priority2 = rover.ML.MissionQueue.Priority2[1:]
-		rover.Logger.Infof("Mission", "Dequeued mission %d from Priority 2 queue", mission.MsgID)
-		return mission, true
-	}
-
-	// Check Priority 3
-	if len(rover.ML.MissionQueue.Priority3) > 0 {
-		mission := rover.ML.MissionQueue.Priority3[0]
-		rover.ML.MissionQueue.Priority3 = rover.ML.MissionQueue.Priority3[1:]
-		rover.Logger.Infof("Mission", "Dequeued mission %d from Priority 3 queue", mission.MsgID)
-		return mission, true
+	queue := rover.ML.MissionQueue
+	queue.Mu.Lock()
+	defer queue.Mu.Unlock()
+
+	// Check Priority 1 first, then 2 and 3
+	for i, missions := range []*[]ml.MissionData{&queue.Priority1, &queue.Priority2, &queue.Priority3} {
+		if len(*missions) > 0 {
+			mission := (*missions)[0]
+			*missions = (*missions)[1:]
+			queue.Running = mission.MsgID
+			rover.Logger.Infof("Mission", "Dequeued mission %d from Priority %d queue", mission.MsgID, i+1)
+			return mission, true
+		}
 	}
 
 	// No missions available
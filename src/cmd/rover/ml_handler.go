@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"math/rand"
 	"src/config"
 	"src/internal/ml"
 	pl "src/utils/packetsLogic"
+	"time"
 )
 
 // handlePacket processes each packet on a separate goroutine
@@ -19,16 +22,39 @@ func (rover *Rover) handlePacket(pkt ml.Packet) {
 		case ml.MSG_NO_MISSION:
 			// No mission available - implicit ACK already handled by HandleOrderedPacket
 			rover.ML.MissionReceivedChan <- false
+		case ml.MSG_OPEN_ACK:
+			rover.Logger.Infof("MissionLink", "Session opened (mothership ISN %d)", p.SeqNum)
+			rover.signalOpen(nil)
+		case ml.MSG_HELLO_ACK:
+			rover.processHelloAck(p)
 		case ml.MSG_ACK:
 			// Pure ACK - already processed by HandleOrderedPacket, nothing else to do
+		case ml.MSG_ERROR:
+			rover.processError(p)
+		case ml.MSG_PATH_CHALLENGE:
+			rover.processPathChallenge(p)
+		case ml.MSG_PING:
+			rover.processPing(p)
+		case ml.MSG_CANCEL:
+			rover.processCancel(p)
 		default:
 			rover.Logger.Warnf("MissionLink", "Unknown packet type: %d", p.MsgType)
 		}
 	}
 
+	// The first OPEN_ACK tells us where the mothership's sequence numbers start
+	if pkt.MsgType == ml.MSG_OPEN_ACK {
+		rover.ML.CondMu.Lock()
+		if !rover.ML.SessionOpen {
+			rover.ML.SessionOpen = true
+			rover.ML.ExpectedSeq = pkt.SeqNum
+		}
+		rover.ML.CondMu.Unlock()
+	}
+
 	// Determine packet handling options
-	isPureAck := pkt.MsgType == ml.MSG_ACK
-	shouldAutoAck := !isPureAck // Don't ACK an ACK
+	isUnsequenced := pkt.MsgType.IsUnsequenced()
+	shouldAutoAck := !isUnsequenced // Don't ACK an ACK or an error
 
 	pl.HandleOrderedPacket(
 		pkt,
@@ -40,16 +66,248 @@ func (rover *Rover) handlePacket(pkt ml.Packet) {
 		rover.ML.Window,
 		rover.ID,
 		processor,
-		isPureAck,     // skipOrdering: only for pure ACKs
-		shouldAutoAck, // autoAck: send ACK for all except ACK packets
+		isUnsequenced, // skipOrdering: only for pure ACKs and errors
+		shouldAutoAck, // autoAck: send ACK for all except ACK and error packets
 		rover.Logger.CreateLogCallback("PacketHandler"),
 	)
 }
 
+// open starts the MissionLink session: sends MSG_OPEN with our random ISN, the epoch
+// received at registration and the number of the new session, and blocks until the
+// mothership answers with MSG_OPEN_ACK, carrying its own ISN
+// Every session encrypts with its own nonces, so a re-opened session can reuse SeqNums
+func (rover *Rover) open() error {
+	// Wait long enough for every retransmission attempt
+	timeout := time.Duration(config.MAX_RETRIES+1) * config.MAX_RTO
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	rover.ML.SendMu.Lock()
+	err := rover.sendOpen(ctx)
+	rover.ML.SendMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	select {
+	case err := <-rover.ML.OpenChan:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("no OPEN_ACK from mothership after %v", timeout)
+	}
+}
+
+// sendOpen discards what the previous session left in flight (reports go to the outbox) and
+// sends the OPEN of a new session with a fresh ISN
+// Holding SendMu throughout, no other packet takes a SeqNum of the old session after the
+// reset, nor the new ISN before the OPEN
+// Caller must hold rover.ML.SendMu
+func (rover *Rover) sendOpen(ctx context.Context) error {
+	for _, msg := range rover.ML.Window.Reset() {
+		rover.keepUndelivered(msg)
+	}
+
+	isn, err := ml.NewISN()
+	if err != nil {
+		return err
+	}
+	rover.ML.SeqNum = isn
+	rover.ML.CondMu.Lock()
+	rover.ML.SessionOpen = false // The next OPEN_ACK seeds ExpectedSeq again
+	for seq := range rover.ML.Buffer {
+		delete(rover.ML.Buffer, seq)
+	}
+	rover.ML.CondMu.Unlock()
+
+	// Forget answers that belonged to the old session
+	select {
+	case <-rover.ML.OpenChan:
+	default:
+	}
+	select {
+	case <-rover.ML.HelloChan:
+	default:
+	}
+
+	rover.ML.Keys.Session++
+	keys, err := rover.ML.Keys.ForSession(rover.ML.Keys.Session)
+	if err != nil {
+		return fmt.Errorf("could not open session: %v", err)
+	}
+	rover.ML.Window.SetKeys(keys)
+	open := ml.OpenData{Epoch: keys.Epoch, Session: keys.Session}
+
+	err = pl.CreateAndSendPacket(
+		ctx,
+		rover.MLConn.Conn,
+		rover.MLConn.Addr,
+		rover.ID,
+		ml.MSG_OPEN,
+		&rover.ML.SeqNum,
+		0,
+		open.Encode(),
+		rover.ML.Window,
+		nil, // SendMu already held
+		rover.Logger.CreateLogCallback("Open"),
+	)
+	if err != nil {
+		return fmt.Errorf("could not send OPEN: %v", err)
+	}
+	return nil
+}
+
+// close ends the MissionLink session: waits (bounded) for pending packets, then sends
+// MSG_CLOSE so the mothership releases this rover's state, and waits for its ACK
+func (rover *Rover) close() {
+	timeout := time.Duration(config.MAX_RETRIES+1) * config.MAX_RTO
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := rover.ML.Window.WaitUntilIdle(ctx); err != nil {
+		rover.Logger.Warnf("MissionLink", "Closing with %d packets still unacknowledged", rover.ML.Window.GetPendingCount())
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := pl.CreateAndSendPacket(
+		ctx,
+		rover.MLConn.Conn,
+		rover.MLConn.Addr,
+		rover.ID,
+		ml.MSG_CLOSE,
+		&rover.ML.SeqNum,
+		0,
+		[]byte{},
+		rover.ML.Window,
+		&rover.ML.SendMu,
+		rover.Logger.CreateLogCallback("Close"),
+	)
+	if err == nil {
+		err = rover.ML.Window.WaitUntilIdle(ctx)
+	}
+	if err != nil {
+		rover.Logger.Warnf("MissionLink", "Session not closed cleanly: %v", err)
+		return
+	}
+	rover.Logger.Infof("MissionLink", "Session closed")
+}
+
+// signalOpen reports the outcome of the OPEN handshake without blocking
+func (rover *Rover) signalOpen(err error) {
+	select {
+	case rover.ML.OpenChan <- err:
+	default:
+	}
+}
+
+// negotiate sends a HELLO advertising this rover's capabilities and blocks until the
+// mothership answers with the negotiated set (HELLO_ACK) or refuses us (MSG_ERROR)
+func (rover *Rover) negotiate() error {
+	hello := ml.HelloData{
+		Version:      ml.PROTOCOL_VERSION,
+		Capabilities: rover.ML.Window.LocalCapabilities(),
+	}
+
+	// Wait long enough for every retransmission attempt
+	timeout := time.Duration(config.MAX_RETRIES+1) * config.MAX_RTO
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := pl.CreateAndSendPacket(
+		ctx,
+		rover.MLConn.Conn,
+		rover.MLConn.Addr,
+		rover.ID,
+		ml.MSG_HELLO,
+		&rover.ML.SeqNum,
+		0,
+		hello.Encode(),
+		rover.ML.Window,
+		&rover.ML.SendMu,
+		rover.Logger.CreateLogCallback("Hello"),
+	)
+	if err != nil {
+		return fmt.Errorf("could not send HELLO: %v", err)
+	}
+
+	select {
+	case err := <-rover.ML.HelloChan:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("no HELLO_ACK from mothership after %v", timeout)
+	}
+}
+
+// processHelloAck stores the capabilities negotiated with the mothership
+func (rover *Rover) processHelloAck(pkt ml.Packet) {
+	var hello ml.HelloData
+	if err := hello.Decode(pkt.Payload); err != nil {
+		rover.signalHello(fmt.Errorf("invalid HELLO_ACK: %v", err))
+		return
+	}
+
+	rover.ML.Window.SetCapabilities(hello.Capabilities)
+	rover.Logger.Infof("MissionLink", "Mothership speaks v%d, negotiated capabilities: %s",
+		hello.Version, ml.CapabilitiesString(hello.Capabilities))
+	rover.signalHello(nil)
+}
+
+// processError handles an error reported by the mothership
+func (rover *Rover) processError(pkt ml.Packet) {
+	var errData ml.ErrorData
+	if err := errData.Decode(pkt.Payload); err != nil {
+		rover.Logger.Errorf("MissionLink", "Invalid error packet: %v", err)
+		return
+	}
+
+	rover.Logger.Errorf("MissionLink", "Mothership refused packet (code %d): %s", errData.Code, errData.Message)
+	if errData.Code == ml.ERR_UNSUPPORTED_VERSION {
+		rover.signalHello(fmt.Errorf("mothership refused protocol v%d: %s", ml.PROTOCOL_VERSION, errData.Message))
+	}
+}
+
+// processPathChallenge echoes the mothership's token back, proving we own our new address
+// (the challenge only reaches us there, and the response comes from the socket it reached)
+func (rover *Rover) processPathChallenge(pkt ml.Packet) {
+	var challenge ml.PathData
+	if err := challenge.Decode(pkt.Payload); err != nil {
+		rover.Logger.Errorf("MissionLink", "Invalid path challenge: %v", err)
+		return
+	}
+
+	rover.Logger.Infof("MissionLink", "Mothership validating our address, sending PATH_RESPONSE")
+	pl.SendPathData(rover.MLConn.Conn, rover.MLConn.Addr, ml.MSG_PATH_RESPONSE, challenge, rover.ML.Window, rover.ID,
+		rover.Logger.CreateLogCallback("PacketHandler"))
+}
+
+// processPing answers the mothership's keepalive probe with the same probe number
+func (rover *Rover) processPing(pkt ml.Packet) {
+	var ping ml.PingData
+	if err := ping.Decode(pkt.Payload); err != nil {
+		rover.Logger.Errorf("MissionLink", "Invalid ping: %v", err)
+		return
+	}
+
+	pl.SendPingData(rover.MLConn.Conn, rover.MLConn.Addr, ml.MSG_PONG, ping, rover.ML.Window, rover.ID,
+		rover.Logger.CreateLogCallback("PacketHandler"))
+}
+
+// signalHello reports the outcome of the negotiation without blocking
+func (rover *Rover) signalHello(err error) {
+	select {
+	case rover.ML.HelloChan <- err:
+	default:
+	}
+}
+
 // processMission extracts and enqueues the mission by priority
 func (rover *Rover) processMission(pkt ml.Packet) {
 	var mission ml.MissionData
-	mission = mission.Decode(pkt.Payload)
+	if err := mission.Decode(pkt.Payload); err != nil {
+		rover.Logger.Errorf("MissionLink", "Invalid mission: %v", err)
+		return
+	}
 
 	// Add mission to appropriate priority queue
 	rover.ML.MissionQueue.Mu.Lock()
@@ -73,55 +331,87 @@ func (rover *Rover) processMission(pkt ml.Packet) {
 	rover.ML.MissionReceivedChan <- true
 }
 
+// processCancel aborts a mission recalled by the mothership
+func (rover *Rover) processCancel(pkt ml.Packet) {
+	var cancel ml.CancelData
+	if err := cancel.Decode(pkt.Payload); err != nil {
+		rover.Logger.Errorf("MissionLink", "Invalid cancel: %v", err)
+		return
+	}
+
+	mission, wasQueued, found := rover.cancelMission(cancel.MissionID)
+	switch {
+	case wasQueued:
+		rover.Logger.Warnf("Mission", "Mission %d cancelled, removed from the queue", mission.MsgID)
+		// Not sent from the receiver goroutine: it may have to wait for ACKs
+		// SendMu orders it with the reports and requests of the mission loop
+		go rover.sendAbortedReport(mission, 0)
+	case found:
+		rover.Logger.Warnf("Mission", "Mission %d cancelled, interrupting it", cancel.MissionID)
+	default:
+		rover.Logger.Infof("Mission", "Mission %d cancelled, but it already finished", cancel.MissionID)
+	}
+}
+
 // receiver continuously reads UDP packets
 func (rover *Rover) receiver() {
-	buf := make([]byte, 2048)
+	buf := make([]byte, 65535) // Largest UDP datagram
+	logf := rover.Logger.CreateLogCallback("MissionLink")
 	// Reception loop
 	for {
-		n, _, err := rover.MLConn.Conn.ReadFromUDP(buf)
+		n, addr, err := rover.MLConn.Conn.ReadFromUDP(buf)
 		if err != nil {
 			rover.Logger.Errorf("MissionLink", "Error reading UDP packet: %v", err)
 			continue
 		}
 
-		// Constructs the packet from received bytes and processes it
-		var pkt ml.Packet
-		pkt.Decode(buf[:n])
+		// Constructs the packet from received bytes, discarding corrupted ones, and processes it
+		pkt, ok := pl.DecodePacket(buf[:n], addr, logf)
+		if !ok {
+			continue
+		}
 		rover.handlePacket(pkt)
 	}
 }
 
 // sendReport serializes and sends a report to the mothership
 // For image capture tasks, sends multiple reports (one per chunk)
-func (rover *Rover) sendReport(mission ml.MissionData, final bool) {
+// seq is the number of the mission's next report, advanced for every report sent
+func (rover *Rover) sendReport(mission ml.MissionData, final bool, seq *uint16) {
 	// Special handling for image capture - send multiple chunks
 	if mission.TaskType == ml.TASK_IMAGE_CAPTURE {
-		rover.sendImageReports(mission, final)
+		rover.sendImageReports(mission, final, seq)
 		return
 	}
 
 	// For other task types, send single report
-	payload := rover.buildReportPayload(mission, final)
+	payload := rover.buildReportPayload(mission, final, *seq)
+	*seq++
 	if payload == nil {
 		return
 	}
 
-	pl.CreateAndSendPacket(
-		rover.MLConn.Conn,
-		rover.MLConn.Addr,
-		rover.ID,
-		ml.MSG_REPORT,
-		&rover.ML.SeqNum,
-		0,
-		payload,
-		rover.ML.Window,
-		nil,
-		rover.Logger.CreateLogCallback("Report"),
-	)
+	rover.transmitReport(payload)
+}
+
+// sendAbortedReport sends the last report of a cancelled mission, flagged as aborted
+// seq is the number of the mission's next report
+func (rover *Rover) sendAbortedReport(mission ml.MissionData, seq uint16) {
+	report := ml.Report{
+		Header: ml.ReportHeader{
+			TaskType:     mission.TaskType,
+			MissionID:    mission.MsgID,
+			Seq:          seq,
+			IsLastReport: true,
+			Aborted:      true,
+		},
+	}
+	rover.transmitReport(report.Encode())
+	rover.Logger.Warnf("Mission", "Mission %d aborted after %d reports", mission.MsgID, seq)
 }
 
 // sendImageReports sends multiple image chunk reports
-func (rover *Rover) sendImageReports(mission ml.MissionData, final bool) {
+func (rover *Rover) sendImageReports(mission ml.MissionData, final bool, seq *uint16) {
 	totalChunks := rover.Devices.Camera.GetTotalChunks()
 
 	// If no image loaded, send empty report
@@ -132,6 +422,10 @@ func (rover *Rover) sendImageReports(mission ml.MissionData, final bool) {
 
 	rover.Logger.Infof("Camera", "Sending %d image chunks for mission %d", totalChunks, mission.MsgID)
 
+	// Parity packets let the mothership rebuild a lost chunk without waiting for its retransmission
+	rover.ML.Window.EnableFEC()
+	defer rover.ML.Window.DisableFEC()
+
 	// Send each chunk as a separate report
 	for i := 0; i < totalChunks; i++ {
 		isLast := final && (i == totalChunks-1)
@@ -145,8 +439,10 @@ func (rover *Rover) sendImageReports(mission ml.MissionData, final bool) {
 		header := ml.ReportHeader{
 			TaskType:     mission.TaskType,
 			MissionID:    mission.MsgID,
+			Seq:          *seq,
 			IsLastReport: isLast,
 		}
+		*seq++
 
 		report := ml.Report{
 			Header:  header,
@@ -155,18 +451,7 @@ func (rover *Rover) sendImageReports(mission ml.MissionData, final bool) {
 
 		payload := report.Encode()
 
-		pl.CreateAndSendPacket(
-			rover.MLConn.Conn,
-			rover.MLConn.Addr,
-			rover.ID,
-			ml.MSG_REPORT,
-			&rover.ML.SeqNum,
-			0,
-			payload,
-			rover.ML.Window,
-			nil,
-			rover.Logger.CreateLogCallback("Report"),
-		)
+		rover.transmitReport(payload)
 
 		rover.Logger.Debugf("Camera", "Sent chunk %d/%d (%d bytes)", i+1, totalChunks, len(chunk))
 	}
@@ -178,6 +463,7 @@ func (rover *Rover) sendRequest() {
 	payload := []byte{rover.ML.MissionQueue.BatchSize}
 
 	pl.CreateAndSendPacket(
+		context.Background(),
 		rover.MLConn.Conn,
 		rover.MLConn.Addr,
 		rover.ID,
@@ -186,16 +472,17 @@ func (rover *Rover) sendRequest() {
 		0,
 		payload,
 		rover.ML.Window,
-		nil,
+		&rover.ML.SendMu,
 		rover.Logger.CreateLogCallback("Request"),
 	)
 }
 
 // buildReportPayload creates a generic report header
-func (rover *Rover) buildReportPayload(mission ml.MissionData, final bool) []byte {
+func (rover *Rover) buildReportPayload(mission ml.MissionData, final bool, seq uint16) []byte {
 	header := ml.ReportHeader{
 		TaskType:     mission.TaskType,
 		MissionID:    mission.MsgID,
+		Seq:          seq,
 		IsLastReport: final,
 	}
 
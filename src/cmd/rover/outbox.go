@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"src/config"
+	"src/internal/ml"
+	pl "src/utils/packetsLogic"
+	"time"
+)
+
+// Store-and-forward of reports.
+// A message given up after MAX_RETRIES leaves a hole the mothership waits for forever, so the
+// session can't carry anything else: undelivered reports are kept in the on-disk outbox, the
+// session is re-opened (next session number, new sequence numbers) and the outbox is replayed.
+// The mothership discards replays it already has, by mission and report number.
+
+// messageGivenUp is called by the window for every message the mothership never acknowledged
+func (rover *Rover) messageGivenUp(msg pl.UndeliveredMessage) {
+	rover.ML.GiveUps.Add(1)
+	rover.keepUndelivered(msg)
+
+	select {
+	case rover.ML.LinkLost <- struct{}{}:
+	default:
+		// Recovery already pending
+	}
+}
+
+// keepUndelivered stores an undelivered report in the outbox
+// An undelivered mission request is answered locally, so manageMissions asks again
+func (rover *Rover) keepUndelivered(msg pl.UndeliveredMessage) {
+	switch msg.MsgType {
+	case ml.MSG_REPORT:
+		if len(msg.Payload) < ml.REPORT_HEADER_SIZE {
+			return
+		}
+		var report ml.Report
+		if err := report.Decode(msg.Payload); err != nil {
+			return
+		}
+		if err := rover.ML.Outbox.Store(report); err != nil {
+			rover.Logger.Errorf("Outbox", "Report %d of mission %d lost: %v", report.Header.Seq, report.Header.MissionID, err)
+			return
+		}
+		rover.Logger.Warnf("Outbox", "Report %d of mission %d stored for replay", report.Header.Seq, report.Header.MissionID)
+	case ml.MSG_REQUEST:
+		select {
+		case rover.ML.MissionReceivedChan <- false:
+		default:
+		}
+	}
+}
+
+// transmitReport sends an encoded report, or stores it while the session is being re-opened
+// A report that gets past the check as the session is re-opened takes a SeqNum after the
+// new OPEN (see sendOpen), and is retransmitted until the new session acknowledges it
+func (rover *Rover) transmitReport(payload []byte) {
+	if rover.ML.Recovering.Load() {
+		rover.keepUndelivered(pl.UndeliveredMessage{MsgType: ml.MSG_REPORT, Payload: payload})
+		return
+	}
+
+	pl.CreateAndSendPacket(
+		context.Background(),
+		rover.MLConn.Conn,
+		rover.MLConn.Addr,
+		rover.ID,
+		ml.MSG_REPORT,
+		&rover.ML.SeqNum,
+		0,
+		payload,
+		rover.ML.Window,
+		&rover.ML.SendMu,
+		rover.Logger.CreateLogCallback("Report"),
+	)
+}
+
+// recoverLink replays what a previous run left in the outbox, then re-opens the session
+// and replays the outbox every time a message is given up
+func (rover *Rover) recoverLink() {
+	rover.replayOutbox()
+
+	for range rover.ML.LinkLost {
+		rover.Logger.Warnf("MissionLink", "Link lost, re-opening the session")
+		rover.ML.Recovering.Store(true)
+		for {
+			err := rover.reopen()
+			if err == nil {
+				break
+			}
+			rover.Logger.Warnf("MissionLink", "Could not re-open the session: %v", err)
+			time.Sleep(config.MAX_RTO)
+		}
+		rover.ML.Recovering.Store(false)
+		rover.Logger.Infof("MissionLink", "Session re-opened")
+
+		rover.replayOutbox()
+	}
+}
+
+// reopen discards the stalled session and opens a new one with fresh sequence numbers
+// Reports still in flight go to the outbox (see sendOpen)
+func (rover *Rover) reopen() error {
+	if err := rover.open(); err != nil {
+		return err
+	}
+	if err := rover.negotiate(); err != nil {
+		return err
+	}
+
+	// Losses reported while re-opening were about the old session
+	select {
+	case <-rover.ML.LinkLost:
+	default:
+	}
+	return nil
+}
+
+// replayOutbox resends the stored reports and deletes them once acknowledged
+// They are kept if a message is given up meanwhile: the next recovery replays them again
+func (rover *Rover) replayOutbox() {
+	reports, err := rover.ML.Outbox.Pending()
+	if err != nil {
+		rover.Logger.Errorf("Outbox", "Error reading outbox: %v", err)
+		return
+	}
+	if len(reports) == 0 {
+		return
+	}
+
+	rover.Logger.Infof("Outbox", "Replaying %d undelivered reports", len(reports))
+	giveUps := rover.ML.GiveUps.Load()
+	for i := range reports {
+		rover.transmitReport(reports[i].Encode())
+	}
+
+	// Everything sent is acknowledged once the window drains without new losses
+	timeout := time.Duration(config.MAX_RETRIES+1) * config.MAX_RTO
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := rover.ML.Window.WaitUntilIdle(ctx)
+		cancel()
+		if rover.ML.GiveUps.Load() != giveUps {
+			rover.Logger.Warnf("Outbox", "Replay interrupted, reports kept for the next attempt")
+			return
+		}
+		if err == nil {
+			break
+		}
+	}
+
+	for i := range reports {
+		if err := rover.ML.Outbox.Remove(reports[i]); err != nil {
+			rover.Logger.Errorf("Outbox", "Error removing delivered report: %v", err)
+		}
+	}
+	rover.Logger.Infof("Outbox", "%d reports replayed", len(reports))
+}
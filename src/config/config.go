@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"math"
 	"os"
 	"time"
 )
@@ -14,8 +15,12 @@ var (
 	TCP_ID_PORT        string
 	UDP_COMM_PORT      string
 	TCP_TELEMETRY_PORT string
+	MTU                int // Largest MissionLink datagram in bytes (bigger messages are fragmented)
 )
 
+// minMTU leaves room for the MissionLink header, AEAD tag, fragment header and some data
+const minMTU = 128
+
 // ==================== RETRANSMISSION & RTO ====================
 var (
 	INITIAL_RTO            time.Duration
@@ -24,6 +29,34 @@ var (
 	MAX_RETRIES            int
 	MAX_PACKETS_IN_FLIGHT  int
 	FAST_RETRANSMIT_THRESH int // Number of duplicate ACKs to trigger fast retransmit (typically 3)
+	INITIAL_CWND           int // Initial congestion window in packets (slow start restarts here after a timeout)
+	REORDER_BUFFER_SIZE    int // Maximum out-of-order packets buffered per peer (advertised to the sender as rwnd)
+)
+
+// ==================== MISSIONLINK KEEPALIVE ====================
+var (
+	KEEPALIVE_INTERVAL   time.Duration // Interval between MSG_PING probes to each rover (0 disables keepalive)
+	KEEPALIVE_MAX_MISSED int           // Consecutive unanswered PINGs before a rover's MissionLink is degraded
+)
+
+// ==================== COMPRESSION ====================
+var (
+	COMPRESSION_ENABLED   bool            // Offer payload compression to the peer (CAP_COMPRESSION)
+	COMPRESSION_THRESHOLD int             // Smallest payload in bytes worth compressing
+	COMPRESSION_TYPES     map[string]bool // Message types whose payloads are compressed (e.g. "MSG_REPORT")
+)
+
+// ==================== FORWARD ERROR CORRECTION ====================
+var (
+	FEC_ENABLED    bool // Offer XOR parity of image chunk streams to the peer (CAP_FEC)
+	FEC_GROUP_SIZE int  // Report packets protected by each MSG_FEC parity packet
+)
+
+// FEC group bounds: a parity packet must protect at least two packets and its header
+// describes at most ml.MAX_FEC_GROUP of them
+const (
+	minFECGroup = 2
+	maxFECGroup = 16
 )
 
 // ==================== TELEMETRY ====================
@@ -47,6 +80,7 @@ var (
 	MISSION_QUEUE_SIZE     int
 	EVENT_LOGGER_SIZE      int
 	MAX_MISSIONS_PER_ROVER uint8
+	SCHEDULER_POLICY       string // How queued missions are handed to rovers: fifo, priority, nearest or battery
 )
 
 // ==================== MOVEMENT & PHYSICS ====================
@@ -73,10 +107,18 @@ var (
 	INSTALL_SUCCESS_CHANCE float64
 )
 
+// ==================== SECURITY ====================
+var (
+	ENCRYPTION_ENABLED bool // Encrypt MissionLink payloads with AES-GCM (mothership setting, announced at registration)
+)
+
 // Config holds the global configuration settings
 type Config struct {
 	MotherIP string
 	TestMode bool // Enable metrics collection for testing
+	RoverID  uint // Rover ID to reclaim after a restart (0 = let the mothership assign one)
+
+	PortOffset int // Added to the rover-facing ports (ID, MissionLink, TelemetryLink), e.g. behind netem-proxy
 }
 
 var GlobalConfig Config
@@ -88,6 +130,7 @@ type jsonConfig struct {
 	TCP_ID_PORT        int `json:"TCP_ID_PORT"`
 	UDP_COMM_PORT      int `json:"UDP_COMM_PORT"`
 	TCP_TELEMETRY_PORT int `json:"TCP_TELEMETRY_PORT"`
+	MTU                int `json:"MTU"`
 
 	// Retransmission
 	INITIAL_RTO_MS        int `json:"INITIAL_RTO_MS"`
@@ -95,6 +138,21 @@ type jsonConfig struct {
 	MAX_RTO_MS            int `json:"MAX_RTO_MS"`
 	MAX_RETRIES           int `json:"MAX_RETRIES"`
 	MAX_PACKETS_IN_FLIGHT int `json:"MAX_PACKETS_IN_FLIGHT"`
+	INITIAL_CWND          int `json:"INITIAL_CWND"`
+	REORDER_BUFFER_SIZE   int `json:"REORDER_BUFFER_SIZE"`
+
+	// Keepalive
+	KEEPALIVE_INTERVAL_MS int `json:"KEEPALIVE_INTERVAL_MS"`
+	KEEPALIVE_MAX_MISSED  int `json:"KEEPALIVE_MAX_MISSED"`
+
+	// Compression
+	COMPRESSION_ENABLED   bool     `json:"COMPRESSION_ENABLED"`
+	COMPRESSION_THRESHOLD int      `json:"COMPRESSION_THRESHOLD"`
+	COMPRESSION_TYPES     []string `json:"COMPRESSION_TYPES"`
+
+	// Forward error correction
+	FEC_ENABLED    bool `json:"FEC_ENABLED"`
+	FEC_GROUP_SIZE int  `json:"FEC_GROUP_SIZE"`
 
 	// Telemetry
 	DEFAULT_TELEMETRY_FREQ_SEC int `json:"DEFAULT_TELEMETRY_FREQ_SEC"`
@@ -109,9 +167,10 @@ type jsonConfig struct {
 	BATTERY_MONITOR_INTERVAL_SEC int `json:"BATTERY_MONITOR_INTERVAL_SEC"`
 
 	// Mothership
-	MISSION_QUEUE_SIZE     int `json:"MISSION_QUEUE_SIZE"`
-	EVENT_LOGGER_SIZE      int `json:"EVENT_LOGGER_SIZE"`
-	MAX_MISSIONS_PER_ROVER int `json:"MAX_MISSIONS_PER_ROVER"`
+	MISSION_QUEUE_SIZE     int    `json:"MISSION_QUEUE_SIZE"`
+	EVENT_LOGGER_SIZE      int    `json:"EVENT_LOGGER_SIZE"`
+	MAX_MISSIONS_PER_ROVER int    `json:"MAX_MISSIONS_PER_ROVER"`
+	SCHEDULER_POLICY       string `json:"SCHEDULER_POLICY"`
 
 	// Movement
 	MAX_SPEED             float64 `json:"MAX_SPEED"`
@@ -130,6 +189,9 @@ type jsonConfig struct {
 	CAMERA_CHUNK_SIZE      int     `json:"CAMERA_CHUNK_SIZE"`
 	CAMERA_FAIL_CHANCE     float32 `json:"CAMERA_FAIL_CHANCE"`
 	INSTALL_SUCCESS_CHANCE float64 `json:"INSTALL_SUCCESS_CHANCE"`
+
+	// Security
+	ENCRYPTION_ENABLED bool `json:"ENCRYPTION_ENABLED"`
 }
 
 // InitConfig initializes the global configuration from command-line flags and config.json
@@ -137,7 +199,16 @@ func InitConfig(isRover bool, print bool) {
 	// Default IP is localhost
 	flag.StringVar(&GlobalConfig.MotherIP, "ms-ip", "127.0.0.1", "Mother Ship IP Address")
 	flag.BoolVar(&GlobalConfig.TestMode, "test-mode", false, "Enable metrics collection for testing")
+	flag.IntVar(&GlobalConfig.PortOffset, "port-offset", 0, "Offset added to the ID, MissionLink and TelemetryLink ports")
+	if isRover {
+		flag.UintVar(&GlobalConfig.RoverID, "id", 0, "Rover ID to reclaim after a restart (0 = assign a new one)")
+	}
 	flag.Parse()
+	if GlobalConfig.RoverID > math.MaxUint8 {
+		fmt.Fprintf(os.Stderr, "invalid value %d for flag -id: rover IDs go up to %d\n", GlobalConfig.RoverID, math.MaxUint8)
+		flag.Usage()
+		os.Exit(2)
+	}
 
 	// Read config from config.json
 	file, err := os.Open("config.json")
@@ -153,9 +224,13 @@ func InitConfig(isRover bool, print bool) {
 
 	// Assign Network Ports
 	API_PORT = fmt.Sprintf("%d", conf.API_PORT)
-	TCP_ID_PORT = fmt.Sprintf("%d", conf.TCP_ID_PORT)
-	UDP_COMM_PORT = fmt.Sprintf("%d", conf.UDP_COMM_PORT)
-	TCP_TELEMETRY_PORT = fmt.Sprintf("%d", conf.TCP_TELEMETRY_PORT)
+	TCP_ID_PORT = fmt.Sprintf("%d", conf.TCP_ID_PORT+GlobalConfig.PortOffset)
+	UDP_COMM_PORT = fmt.Sprintf("%d", conf.UDP_COMM_PORT+GlobalConfig.PortOffset)
+	TCP_TELEMETRY_PORT = fmt.Sprintf("%d", conf.TCP_TELEMETRY_PORT+GlobalConfig.PortOffset)
+	MTU = conf.MTU
+	if MTU < minMTU {
+		MTU = minMTU
+	}
 
 	// Assign Retransmission Settings
 	INITIAL_RTO = time.Duration(conf.INITIAL_RTO_MS) * time.Millisecond
@@ -164,6 +239,39 @@ func InitConfig(isRover bool, print bool) {
 	MAX_RETRIES = conf.MAX_RETRIES
 	MAX_PACKETS_IN_FLIGHT = conf.MAX_PACKETS_IN_FLIGHT
 	FAST_RETRANSMIT_THRESH = 3 // Standard TCP value: 3 duplicate ACKs trigger fast retransmit
+	INITIAL_CWND = conf.INITIAL_CWND
+	if INITIAL_CWND < 1 {
+		INITIAL_CWND = 1
+	}
+	REORDER_BUFFER_SIZE = conf.REORDER_BUFFER_SIZE
+	if REORDER_BUFFER_SIZE < 1 {
+		REORDER_BUFFER_SIZE = 1
+	}
+
+	// Assign Keepalive Settings
+	KEEPALIVE_INTERVAL = time.Duration(conf.KEEPALIVE_INTERVAL_MS) * time.Millisecond
+	KEEPALIVE_MAX_MISSED = conf.KEEPALIVE_MAX_MISSED
+	if KEEPALIVE_MAX_MISSED < 1 {
+		KEEPALIVE_MAX_MISSED = 1
+	}
+
+	// Assign Compression Settings
+	COMPRESSION_ENABLED = conf.COMPRESSION_ENABLED
+	COMPRESSION_THRESHOLD = conf.COMPRESSION_THRESHOLD
+	COMPRESSION_TYPES = make(map[string]bool, len(conf.COMPRESSION_TYPES))
+	for _, msgType := range conf.COMPRESSION_TYPES {
+		COMPRESSION_TYPES[msgType] = true
+	}
+
+	// Assign Forward Error Correction Settings
+	FEC_ENABLED = conf.FEC_ENABLED
+	FEC_GROUP_SIZE = conf.FEC_GROUP_SIZE
+	if FEC_GROUP_SIZE < minFECGroup {
+		FEC_GROUP_SIZE = minFECGroup
+	}
+	if FEC_GROUP_SIZE > maxFECGroup {
+		FEC_GROUP_SIZE = maxFECGroup
+	}
 
 	// Assign Telemetry Settings
 	DEFAULT_TELEMETRY_FREQ = time.Duration(conf.DEFAULT_TELEMETRY_FREQ_SEC) * time.Second
@@ -181,6 +289,10 @@ func InitConfig(isRover bool, print bool) {
 	MISSION_QUEUE_SIZE = conf.MISSION_QUEUE_SIZE
 	EVENT_LOGGER_SIZE = conf.EVENT_LOGGER_SIZE
 	MAX_MISSIONS_PER_ROVER = uint8(conf.MAX_MISSIONS_PER_ROVER)
+	SCHEDULER_POLICY = conf.SCHEDULER_POLICY
+	if SCHEDULER_POLICY == "" {
+		SCHEDULER_POLICY = "fifo"
+	}
 
 	// Assign Movement Settings
 	MAX_SPEED = conf.MAX_SPEED
@@ -200,6 +312,9 @@ func InitConfig(isRover bool, print bool) {
 	CAMERA_FAIL_CHANCE = conf.CAMERA_FAIL_CHANCE
 	INSTALL_SUCCESS_CHANCE = conf.INSTALL_SUCCESS_CHANCE
 
+	// Assign Security Settings
+	ENCRYPTION_ENABLED = conf.ENCRYPTION_ENABLED
+
 	if print {
 		PrintConfig()
 	}
@@ -229,6 +344,11 @@ func GetMotherTelemetryAddr() string {
 	return GlobalConfig.MotherIP + ":" + TCP_TELEMETRY_PORT
 }
 
+// GetRequestedRoverID returns the rover ID asked for in the ID handshake (0 = any)
+func GetRequestedRoverID() uint8 {
+	return uint8(GlobalConfig.RoverID)
+}
+
 // IsTestMode returns true if test mode is enabled
 func IsTestMode() bool {
 	return GlobalConfig.TestMode
@@ -2,7 +2,9 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"sync"
 
@@ -14,6 +16,28 @@ import (
 // DataProvider is a function that provides data for an API endpoint
 type DataProvider func() interface{}
 
+// RequestHandler serves a REST request from its path variables (e.g. {id}) and body
+// Errors are answered with the status of an HTTPError, or 500 for any other error
+type RequestHandler func(vars map[string]string, body []byte) (interface{}, error)
+
+// HTTPError is an error answered with a specific HTTP status
+type HTTPError struct {
+	Status  int
+	Message string
+}
+
+func (e *HTTPError) Error() string {
+	return e.Message
+}
+
+// NewHTTPError creates an HTTPError with a formatted message
+func NewHTTPError(status int, format string, args ...any) *HTTPError {
+	return &HTTPError{Status: status, Message: fmt.Sprintf(format, args...)}
+}
+
+// maxBodySize bounds the body of a request
+const maxBodySize = 1 << 20
+
 // APIServer represents the API server with REST and WebSocket capabilities.
 type APIServer struct {
 	upgrader  websocket.Upgrader
@@ -50,6 +74,34 @@ func (api *APIServer) RegisterEndpoint(path string, method string, provider Data
 	}).Methods(method)
 }
 
+// RegisterHandler registers a REST endpoint served by a RequestHandler
+func (api *APIServer) RegisterHandler(path string, method string, handler RequestHandler) {
+	api.router.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		if err != nil {
+			writeError(w, NewHTTPError(http.StatusRequestEntityTooLarge, "request body too large"))
+			return
+		}
+		data, err := handler(mux.Vars(r), body)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		json.NewEncoder(w).Encode(data)
+	}).Methods(method)
+}
+
+// writeError answers with the status of err and a JSON {"error": message}
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		status = httpErr.Status
+	}
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
 
 // Start starts the API server on the specified port
 func (api *APIServer) Start(port string) {
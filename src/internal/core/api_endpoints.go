@@ -12,6 +12,11 @@ func (ms *MotherShip) setupAPIEndpoints() {
 
     // Endpoint: Lists all missions (with detailed parsing of reports)
     ms.APIServer.RegisterEndpoint("/api/missions", "GET", ms.handleListMissions)
+
+    // Endpoints: Create, bulk-import and edit (while queued) missions
+    ms.APIServer.RegisterHandler("/api/missions", "POST", ms.handleCreateMission)
+    ms.APIServer.RegisterHandler("/api/missions/import", "POST", ms.handleImportMissions)
+    ms.APIServer.RegisterHandler("/api/missions/{id}", "PUT", ms.handleEditMission)
 }
 
 // Handler to list all connected rovers.
@@ -26,81 +31,15 @@ func (ms *MotherShip) handleListMissions() interface{} {
     missions := ms.MissionManager.ListMissions()
     var result []map[string]interface{}
     for _, m := range missions {
-        var parsedReports []interface{}
-        for _, rep := range m.Report {
-            switch rep.Header.TaskType {
-            case ml.TASK_IMAGE_CAPTURE:
-                var img ml.ImageReportData
-                img.DecodePayload(rep.Payload)
-                parsedReports = append(parsedReports, map[string]interface{}{
-                    "taskType":     rep.Header.TaskType,
-                    "missionId":    rep.Header.MissionID,
-                    "chunkId":      img.ChunkID,
-                    "data":         img.Data,
-                    "isLastReport": rep.Header.IsLastReport,
-                })
-            case ml.TASK_SAMPLE_COLLECTION:
-                var sample ml.SampleReportData
-                sample.DecodePayload(rep.Payload)
-                comps := make([]map[string]interface{}, len(sample.Components))
-                for i, c := range sample.Components {
-                    comps[i] = map[string]interface{}{
-                        "name":       c.Name,
-                        "percentage": c.Percentage,
-                    }
-                }
-                parsedReports = append(parsedReports, map[string]interface{}{
-                    "taskType":     rep.Header.TaskType,
-                    "missionId":    rep.Header.MissionID,
-                    "numSamples":   len(sample.Components),
-                    "components":   comps,
-                    "isLastReport": rep.Header.IsLastReport,
-                })
-            case ml.TASK_ENV_ANALYSIS:
-                var env ml.EnvReportData
-                env.DecodePayload(rep.Payload)
-                parsedReports = append(parsedReports, map[string]interface{}{
-                    "taskType":     rep.Header.TaskType,
-                    "missionId":    rep.Header.MissionID,
-                    "temp":         env.Temp,
-                    "oxygen":       env.Oxygen,
-                    "pressure":     env.Pressure,
-                    "humidity":     env.Humidity,
-                    "windSpeed":    env.WindSpeed,
-                    "radiation":    env.Radiation,
-                    "isLastReport": rep.Header.IsLastReport,
-                })
-            case ml.TASK_REPAIR_RESCUE:
-                var repair ml.RepairReportData
-                repair.DecodePayload(rep.Payload)
-                parsedReports = append(parsedReports, map[string]interface{}{
-                    "taskType":     rep.Header.TaskType,
-                    "missionId":    rep.Header.MissionID,
-                    "problemId":    repair.ProblemID,
-                    "repairable":   repair.Repairable,
-                    "isLastReport": rep.Header.IsLastReport,
-                })
-            case ml.TASK_TOPO_MAPPING:
-                var topo ml.TopoReportData
-                topo.DecodePayload(rep.Payload)
-                parsedReports = append(parsedReports, map[string]interface{}{
-                    "taskType":     rep.Header.TaskType,
-                    "missionId":    rep.Header.MissionID,
-                    "latitude":     topo.Latitude,
-                    "longitude":    topo.Longitude,
-                    "height":       topo.Height,
-                    "isLastReport": rep.Header.IsLastReport,
-                })
-            case ml.TASK_INSTALLATION:
-                var inst ml.InstallReportData
-                inst.DecodePayload(rep.Payload)
-                parsedReports = append(parsedReports, map[string]interface{}{
-                    "taskType":     rep.Header.TaskType,
-                    "missionId":    rep.Header.MissionID,
-                    "success":      inst.Success,
-                    "isLastReport": rep.Header.IsLastReport,
-                })
-            }
+        parsedReports := parseReports(m.Report)
+        // Reports of the rovers the mission was taken from (see RequeueRoverMissions)
+        previousAttempts := make([]map[string]interface{}, 0, len(m.PreviousAttempts))
+        for _, attempt := range m.PreviousAttempts {
+            previousAttempts = append(previousAttempts, map[string]interface{}{
+                "idRover":        attempt.IDRover,
+                "reports":        parseReports(attempt.Report),
+                "missingReports": attempt.MissingReports,
+            })
         }
         // Adds reconstructed image if applicable
         assembledImage := m.AssembleImage()
@@ -120,8 +59,109 @@ func (ms *MotherShip) handleListMissions() interface{} {
             "state":          m.State,
             "coordinate":     m.Coordinate,
             "reports":        parsedReports,
+            "missingReports": m.MissingReports,
+            "previousAttempts": previousAttempts,
             "assembledImage": assembledImageBase64,
         })
     }
     return result
-}
\ No newline at end of file
+}
+
+// parseReports decodes the payload of every report, leaving malformed ones out.
+func parseReports(reports []ml.Report) []interface{} {
+    var parsedReports []interface{}
+    for _, rep := range reports {
+        switch rep.Header.TaskType {
+        case ml.TASK_IMAGE_CAPTURE:
+            var img ml.ImageReportData
+            if err := img.DecodePayload(rep.Payload); err != nil {
+                continue // Malformed report, not listed
+            }
+            parsedReports = append(parsedReports, map[string]interface{}{
+                "taskType":     rep.Header.TaskType,
+                "missionId":    rep.Header.MissionID,
+                "seq":          rep.Header.Seq,
+                "chunkId":      img.ChunkID,
+                "data":         img.Data,
+                "isLastReport": rep.Header.IsLastReport,
+            })
+        case ml.TASK_SAMPLE_COLLECTION:
+            var sample ml.SampleReportData
+            if err := sample.DecodePayload(rep.Payload); err != nil {
+                continue
+            }
+            comps := make([]map[string]interface{}, len(sample.Components))
+            for i, c := range sample.Components {
+                comps[i] = map[string]interface{}{
+                    "name":       c.Name,
+                    "percentage": c.Percentage,
+                }
+            }
+            parsedReports = append(parsedReports, map[string]interface{}{
+                "taskType":     rep.Header.TaskType,
+                "missionId":    rep.Header.MissionID,
+                "seq":          rep.Header.Seq,
+                "numSamples":   len(sample.Components),
+                "components":   comps,
+                "isLastReport": rep.Header.IsLastReport,
+            })
+        case ml.TASK_ENV_ANALYSIS:
+            var env ml.EnvReportData
+            if err := env.DecodePayload(rep.Payload); err != nil {
+                continue
+            }
+            parsedReports = append(parsedReports, map[string]interface{}{
+                "taskType":     rep.Header.TaskType,
+                "missionId":    rep.Header.MissionID,
+                "seq":          rep.Header.Seq,
+                "temp":         env.Temp,
+                "oxygen":       env.Oxygen,
+                "pressure":     env.Pressure,
+                "humidity":     env.Humidity,
+                "windSpeed":    env.WindSpeed,
+                "radiation":    env.Radiation,
+                "isLastReport": rep.Header.IsLastReport,
+            })
+        case ml.TASK_REPAIR_RESCUE:
+            var repair ml.RepairReportData
+            if err := repair.DecodePayload(rep.Payload); err != nil {
+                continue
+            }
+            parsedReports = append(parsedReports, map[string]interface{}{
+                "taskType":     rep.Header.TaskType,
+                "missionId":    rep.Header.MissionID,
+                "seq":          rep.Header.Seq,
+                "problemId":    repair.ProblemID,
+                "repairable":   repair.Repairable,
+                "isLastReport": rep.Header.IsLastReport,
+            })
+        case ml.TASK_TOPO_MAPPING:
+            var topo ml.TopoReportData
+            if err := topo.DecodePayload(rep.Payload); err != nil {
+                continue
+            }
+            parsedReports = append(parsedReports, map[string]interface{}{
+                "taskType":     rep.Header.TaskType,
+                "missionId":    rep.Header.MissionID,
+                "seq":          rep.Header.Seq,
+                "latitude":     topo.Latitude,
+                "longitude":    topo.Longitude,
+                "height":       topo.Height,
+                "isLastReport": rep.Header.IsLastReport,
+            })
+        case ml.TASK_INSTALLATION:
+            var inst ml.InstallReportData
+            if err := inst.DecodePayload(rep.Payload); err != nil {
+                continue
+            }
+            parsedReports = append(parsedReports, map[string]interface{}{
+                "taskType":     rep.Header.TaskType,
+                "missionId":    rep.Header.MissionID,
+                "seq":          rep.Header.Seq,
+                "success":      inst.Success,
+                "isLastReport": rep.Header.IsLastReport,
+            })
+        }
+    }
+    return parsedReports
+}
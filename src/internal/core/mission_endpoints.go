@@ -0,0 +1,104 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"src/internal/api"
+	"src/internal/ml"
+	"strconv"
+)
+
+// Missions are sent in the format of missions.json, e.g.
+//
+//	{"TaskType": 2, "Duration": 100, "UpdateFrequency": 1, "Priority": 2,
+//	 "Coordinate": {"Latitude": -0.78, "Longitude": -0.42}}
+//
+// Duration and UpdateFrequency are in seconds. ID, rover, state and reports are set by the mothership.
+
+// handleCreateMission serves POST /api/missions
+// The new mission is enqueued right away and returned with its ID
+func (ms *MotherShip) handleCreateMission(_ map[string]string, body []byte) (interface{}, error) {
+	var mission ml.MissionState
+	if err := decodeMission(body, &mission); err != nil {
+		return nil, err
+	}
+
+	created, err := ms.CreateMission(mission)
+	if err != nil {
+		return nil, api.NewHTTPError(http.StatusServiceUnavailable, "%v", err)
+	}
+	ms.Logger.Infof("API", "📋 Mission %d created (task %d, priority %d)", created.ID, created.TaskType, created.Priority)
+	ms.APIServer.PublishUpdate("mission_queued", created)
+	return created, nil
+}
+
+// handleImportMissions serves POST /api/missions/import, with a JSON array of missions
+// Nothing is imported unless every mission is valid and fits in the mission queue
+func (ms *MotherShip) handleImportMissions(_ map[string]string, body []byte) (interface{}, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, api.NewHTTPError(http.StatusBadRequest, "invalid JSON array: %v", err)
+	}
+	missions := make([]ml.MissionState, len(raw))
+	for i := range raw {
+		if err := decodeMission(raw[i], &missions[i]); err != nil {
+			return nil, api.NewHTTPError(http.StatusBadRequest, "mission %d: %v", i, err)
+		}
+	}
+	if free := ms.Scheduler.Free(); len(missions) > free {
+		return nil, api.NewHTTPError(http.StatusServiceUnavailable, "mission queue has room for %d missions, %d sent", free, len(missions))
+	}
+
+	created := make([]ml.MissionState, 0, len(missions))
+	for _, mission := range missions {
+		m, err := ms.CreateMission(mission)
+		if err != nil {
+			// The queue filled up meanwhile (missions requeued from a rover)
+			return nil, api.NewHTTPError(http.StatusServiceUnavailable, "%v (%d of %d missions imported)", err, len(created), len(missions))
+		}
+		ms.APIServer.PublishUpdate("mission_queued", m)
+		created = append(created, m)
+	}
+	ms.Logger.Infof("API", "📋 %d missions imported", len(created))
+	return created, nil
+}
+
+// handleEditMission serves PUT /api/missions/{id}
+// Only missions still waiting in the mission queue can be edited: once assigned, the rover has its own copy
+func (ms *MotherShip) handleEditMission(vars map[string]string, body []byte) (interface{}, error) {
+	id, err := strconv.ParseUint(vars["id"], 10, 16)
+	if err != nil {
+		return nil, api.NewHTTPError(http.StatusBadRequest, "invalid mission id %q", vars["id"])
+	}
+	var edited ml.MissionState
+	if err := decodeMission(body, &edited); err != nil {
+		return nil, err
+	}
+
+	mission, err := ms.MissionManager.EditMission(uint16(id), edited)
+	switch {
+	case errors.Is(err, ml.ErrMissionNotFound):
+		return nil, api.NewHTTPError(http.StatusNotFound, "mission %d not found", id)
+	case errors.Is(err, ml.ErrMissionNotQueued):
+		return nil, api.NewHTTPError(http.StatusConflict, "mission %d is %s, only queued missions can be edited", id, mission.State)
+	case err != nil:
+		return nil, err
+	}
+
+	ms.Scheduler.Update(mission)
+	ms.Logger.Infof("API", "✏️ Mission %d edited", mission.ID)
+	ms.APIServer.PublishUpdate("mission_update", mission)
+	return mission, nil
+}
+
+// decodeMission parses and validates a mission sent to the API
+func decodeMission(data []byte, mission *ml.MissionState) error {
+	if err := json.Unmarshal(data, mission); err != nil {
+		return api.NewHTTPError(http.StatusBadRequest, "invalid mission JSON: %v", err)
+	}
+	if err := mission.Validate(); err != nil {
+		return api.NewHTTPError(http.StatusBadRequest, "invalid mission: %v", err)
+	}
+	return nil
+}
@@ -5,43 +5,75 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"src/config"
 	"src/internal/api"
 	"src/internal/ml"
 	"src/internal/ts"
 	"src/utils/logger"
 	pl "src/utils/packetsLogic"
 	"sync"
+	"time"
 )
 
 // RoverState maintain the state of each rover connected to the mothership
 type RoverState struct {
-	Addr             *net.UDPAddr         // Address of the rover
+	Addr             *net.UDPAddr         // Address of the rover (read with GetAddr once the session is running)
+	addrMu           sync.RWMutex         // Protects Addr against path migration
 	SeqNum           uint32               // Sequence number for sending packets to the rover (ML)
-	ExpectedSeq      uint32            // Expected sequence number for receiving packets from the rover (ML)
+	ExpectedSeq      uint32               // Expected sequence number for receiving packets from the rover (ML)
+	RemoteISN        uint32               // Initial sequence number of the rover's MSG_OPEN (identifies the session)
+	Epoch            uint32               // Epoch of the rover process that opened the session
 	Buffer           map[uint32]ml.Packet // Buffer for out-of-order packets (ML)
 	WindowLock       sync.Mutex           // Mutex for sliding window operations
 	Window           *pl.Window           // Sliding window specific to this rover
 	NumberOfMissions uint8                // Number of missions rover is currently handling
+
+	// Path validation after an address change (NAT rebinding), guarded by MotherShip.Mu
+	PendingAddr     *net.UDPAddr // Candidate address waiting for a MSG_PATH_RESPONSE
+	PathChallenge   ml.PathData  // Token sent to PendingAddr
+	ChallengeSentAt time.Time    // When the challenge was sent (re-sent after the RTO)
+
+	// MissionLink liveness (MSG_PING / MSG_PONG), guarded by MotherShip.Mu
+	PingSeq     uint32 // Number of the last PING sent
+	PingPending bool   // Whether the last PING is still waiting for its PONG
+	MissedPings int    // Consecutive PINGs left unanswered
+	MLHealth    string // ts.ML_HEALTHY or ts.ML_DEGRADED
+}
+
+// GetAddr returns the rover's current address
+func (rs *RoverState) GetAddr() *net.UDPAddr {
+	rs.addrMu.RLock()
+	defer rs.addrMu.RUnlock()
+	return rs.Addr
+}
+
+// SetAddr migrates the rover to a validated new address
+func (rs *RoverState) SetAddr(addr *net.UDPAddr) {
+	rs.addrMu.Lock()
+	rs.Addr = addr
+	rs.addrMu.Unlock()
+	rs.Window.MigrateAddr(addr)
 }
 
 // MotherShip represents the central control system managing multiple rovers
 type MotherShip struct {
-	Conn           *net.UDPConn          // UDP connection for communication with rovers
-	Rovers         map[uint8]*RoverState // key: rover ID
-	MissionManager *ml.MissionManager    // Manages missions
-	MissionQueue   chan ml.MissionState  // Queue of missions to be assigned
-	Mu             sync.Mutex            // Mutex for concurrent access to Rovers map
-	RoverInfo      *ts.RoverManager      // Manages rover telemetry states
-	APIServer      *api.APIServer        // API server for handling REST endpoints
-	Logger         *logger.Logger        // Logger for logging events
+	Conn           pl.PacketConn             // UDP connection for communication with rovers
+	Rovers         map[uint8]*RoverState     // key: rover ID
+	RoverKeys      map[uint8]*ml.SessionKeys // Session keys negotiated during the ID handshake, key: rover ID
+	MissionManager *ml.MissionManager        // Manages missions
+	Scheduler      *Scheduler                // Missions waiting to be assigned
+	Mu             sync.Mutex                // Mutex for concurrent access to Rovers map
+	RoverInfo      *ts.RoverManager          // Manages rover telemetry states
+	APIServer      *api.APIServer            // API server for handling REST endpoints
+	Logger         *logger.Logger            // Logger for logging events
 }
 
 // NewMotherShip creates and initializes a new MotherShip instance
 func NewMotherShip() *MotherShip {
 	ms := &MotherShip{
 		Rovers:         make(map[uint8]*RoverState),
+		RoverKeys:      make(map[uint8]*ml.SessionKeys),
 		MissionManager: ml.NewMissionManager(),
-		MissionQueue:   make(chan ml.MissionState, 100),
 		Mu:             sync.Mutex{},
 		RoverInfo:      ts.NewRoverManager(),
 		APIServer:      api.NewAPIServer(),
@@ -60,8 +92,15 @@ func NewMotherShip() *MotherShip {
 	}
 	ms.Logger = log
 
+	policy, err := NewPolicy(config.SCHEDULER_POLICY)
+	if err != nil {
+		ms.Logger.Errorf("MotherShip", "%v", err)
+		return nil
+	}
+	ms.Scheduler = NewScheduler(policy, config.MISSION_QUEUE_SIZE)
+
 	// Load initial missions from JSON file
-	err = loadMissionsFromJSON("../assets/missions.json", ms.MissionQueue)
+	err = ms.loadMissionsFromJSON("../assets/missions.json")
 	if err != nil {
 		ms.Logger.Errorf("MotherShip", "erro ao carregar missões iniciais: %v", err)
 		return nil
@@ -74,7 +113,7 @@ func NewMotherShip() *MotherShip {
 }
 
 // loadMissionsFromJSON read the missions from a JSON file and enqueue them
-func loadMissionsFromJSON(filename string, queue chan ml.MissionState) error {
+func (ms *MotherShip) loadMissionsFromJSON(filename string) error {
 	file, err := os.Open(filename)
 	if err != nil {
 		return fmt.Errorf("error opening file: %v", err)
@@ -91,26 +130,78 @@ func loadMissionsFromJSON(filename string, queue chan ml.MissionState) error {
 		return fmt.Errorf("error unmarshaling JSON: %v", err)
 	}
 
-	// Assign incremental IDs to missions
 	for i := range missions {
-		missions[i].ID = uint16(i + 1) // IDs start from 1
-
-		queue <- missions[i]
+		if err := missions[i].Validate(); err != nil {
+			return fmt.Errorf("mission %d: %v", i+1, err)
+		}
+		if _, err := ms.CreateMission(missions[i]); err != nil {
+			return err
+		}
 	}
 
 	fmt.Printf("📋 %d missions enqueued\n", len(missions))
 	return nil
 }
 
+// CreateMission gives a new mission the next free ID and enqueues it
+// Returns the mission as enqueued
+func (ms *MotherShip) CreateMission(mission ml.MissionState) (ml.MissionState, error) {
+	id, err := ms.MissionManager.NewMissionID()
+	if err != nil {
+		return ml.MissionState{}, err
+	}
+	mission.ID = id
+	mission.CreatedAt = time.Time{}
+	mission.Report = nil
+	mission.MissingReports = nil
+	mission.PreviousAttempts = nil
+	return ms.EnqueueMission(mission)
+}
+
+// EnqueueMission registers a mission as "Queued" and hands it to the scheduler
+// Queued missions are kept in the MissionManager too, so they can be listed and cancelled
+// Returns the mission as enqueued
+func (ms *MotherShip) EnqueueMission(mission ml.MissionState) (ml.MissionState, error) {
+	mission.IDRover = 0
+	mission.State = "Queued"
+	mission.LastUpdate = time.Now()
+	stored := mission
+	ms.MissionManager.AddMission(&stored)
+
+	if err := ms.Scheduler.Push(mission); err != nil {
+		ms.MissionManager.DeleteMission(mission.ID)
+		return ml.MissionState{}, fmt.Errorf("%v, mission %d not enqueued", err, mission.ID)
+	}
+	return mission, nil
+}
+
+// RoverView returns what the scheduler knows about a rover, from its telemetry
+func (ms *MotherShip) RoverView(roverID uint8) RoverView {
+	view := RoverView{ID: roverID}
+	if rover, ok := ms.RoverInfo.Snapshot(roverID); ok {
+		view.HasTelemetry = true
+		view.Position = rover.Position
+		view.Battery = rover.Battery
+	}
+	return view
+}
+
+// SetRoverKeys stores the session keys negotiated with a rover
+func (ms *MotherShip) SetRoverKeys(roverID uint8, keys *ml.SessionKeys) {
+	ms.Mu.Lock()
+	defer ms.Mu.Unlock()
+	ms.RoverKeys[roverID] = keys
+}
+
 // NewRoverState cria e inicializa um novo estado de rover para a MotherShip
-func NewRoverState(addr *net.UDPAddr, seqNum uint32) *RoverState {
+func NewRoverState(roverID uint8, addr *net.UDPAddr, seqNum uint32, keys *ml.SessionKeys) *RoverState {
 	return &RoverState{
 		Addr:             addr,
 		SeqNum:           seqNum,
 		ExpectedSeq:      seqNum,
 		Buffer:           make(map[uint32]ml.Packet),
 		WindowLock:       sync.Mutex{},
-		Window:           pl.NewWindow(),
+		Window:           pl.NewWindow(roverID, keys),
 		NumberOfMissions: 0,
 	}
 }
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"math"
 	"src/config"
 	"src/internal/devices"
@@ -19,7 +20,9 @@ func CalculateDistance(from, to utils.Coordinate) float64 {
 }
 
 // MoveTo moves the rover to target coordinates, updating GPS and consuming battery
+// The movement stops where it is once ctx is cancelled, returning ctx.Err()
 func MoveTo(
+	ctx context.Context,
 	currentPos *utils.Coordinate,
 	target utils.Coordinate,
 	gps devices.GPS,
@@ -99,7 +102,17 @@ func MoveTo(
 			})
 		}
 
-		time.Sleep(1 * time.Second)
+		select {
+		case <-ctx.Done():
+			if mockGPS, ok := gps.(*devices.MockGPS); ok {
+				mockGPS.SetSpeed(0)
+			}
+			log.Warn("Movement", "Movement interrupted", map[string]interface{}{
+				"remainingDistance": CalculateDistance(*currentPos, target),
+			})
+			return ctx.Err()
+		case <-time.After(1 * time.Second):
+		}
 	}
 
 	elapsed := time.Since(startTime)
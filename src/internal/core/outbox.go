@@ -0,0 +1,97 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"src/internal/ml"
+	"strings"
+	"sync"
+)
+
+// outboxExt is the extension of the files holding undelivered reports
+const outboxExt = ".report"
+
+// Outbox persists the reports a rover couldn't deliver, so they survive until the
+// MissionLink recovers (or the rover restarts) and can be replayed
+// Each report is a file named after its mission and report number: storing the same
+// report twice is harmless and replays come out in mission order
+type Outbox struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewOutbox opens (creating it if needed) the outbox stored in dir
+func NewOutbox(dir string) (*Outbox, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating outbox %s: %v", dir, err)
+	}
+	return &Outbox{dir: dir}, nil
+}
+
+// path returns the file holding a report
+func (o *Outbox) path(header ml.ReportHeader) string {
+	return filepath.Join(o.dir, fmt.Sprintf("%05d_%05d%s", header.MissionID, header.Seq, outboxExt))
+}
+
+// Store persists a report (written to a temporary file first, so a crash never leaves half a report)
+func (o *Outbox) Store(report ml.Report) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	path := o.path(report.Header)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, report.Encode(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Pending returns every stored report, ordered by mission and report number
+func (o *Outbox) Pending() ([]ml.Report, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	entries, err := os.ReadDir(o.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), outboxExt) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	reports := make([]ml.Report, 0, len(names))
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(o.dir, name))
+		if err != nil {
+			return nil, err
+		}
+		if len(data) < ml.REPORT_HEADER_SIZE {
+			continue // Not written by this build
+		}
+		var report ml.Report
+		if err := report.Decode(data); err != nil {
+			continue
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// Remove deletes a delivered report
+func (o *Outbox) Remove(report ml.Report) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	err := os.Remove(o.path(report.Header))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
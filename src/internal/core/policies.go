@@ -0,0 +1,108 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"src/config"
+	"src/internal/ml"
+	"strings"
+)
+
+// NewPolicy returns the built-in policy with the given name
+// The battery policy estimates costs with the movement and battery settings of config.json
+func NewPolicy(name string) (Policy, error) {
+	switch strings.ToLower(name) {
+	case "fifo":
+		return FIFOPolicy{}, nil
+	case "priority":
+		return PriorityPolicy{}, nil
+	case "nearest":
+		return NearestPolicy{}, nil
+	case "battery":
+		return BatteryPolicy{
+			MovementRate: config.MOVEMENT_BATTERY_RATE,
+			TaskCost:     config.TASK_BATTERY_RATE,
+			Reserve:      config.LOW_BATTERY_LEVEL,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown scheduler policy %q (expected fifo, priority, nearest or battery)", name)
+}
+
+// FIFOPolicy hands out missions in the order they were queued
+type FIFOPolicy struct{}
+
+func (FIFOPolicy) Name() string { return "fifo" }
+
+func (FIFOPolicy) Pick(_ RoverView, _ []ml.MissionState) int {
+	return 0
+}
+
+// PriorityPolicy hands out the most urgent mission first (priority 1), oldest first among equals
+type PriorityPolicy struct{}
+
+func (PriorityPolicy) Name() string { return "priority" }
+
+func (PriorityPolicy) Pick(_ RoverView, queued []ml.MissionState) int {
+	best := 0
+	for i := range queued {
+		if queued[i].Priority < queued[best].Priority {
+			best = i
+		}
+	}
+	return best
+}
+
+// NearestPolicy hands out the mission closest to the rover, oldest first among equals
+// Rovers without telemetry yet get missions in FIFO order
+type NearestPolicy struct{}
+
+func (NearestPolicy) Name() string { return "nearest" }
+
+func (NearestPolicy) Pick(rover RoverView, queued []ml.MissionState) int {
+	if !rover.HasTelemetry {
+		return 0
+	}
+	best, bestDistance := 0, CalculateDistance(rover.Position, queued[0].Coordinate)
+	for i := 1; i < len(queued); i++ {
+		if d := CalculateDistance(rover.Position, queued[i].Coordinate); d < bestDistance {
+			best, bestDistance = i, d
+		}
+	}
+	return best
+}
+
+// BatteryPolicy hands out, by priority, the first mission the rover can carry out
+// without dropping under Reserve: the trip costs MovementRate per unit of distance
+// (as in MoveTo) and the task itself TaskCost
+// Rovers without telemetry yet are assumed to have a full battery
+type BatteryPolicy struct {
+	MovementRate float64 // Battery spent per unit of distance travelled
+	TaskCost     float64 // Battery spent by the task at the destination
+	Reserve      uint8   // Battery level the rover must keep
+}
+
+func (BatteryPolicy) Name() string { return "battery" }
+
+func (p BatteryPolicy) Pick(rover RoverView, queued []ml.MissionState) int {
+	if !rover.HasTelemetry {
+		return PriorityPolicy{}.Pick(rover, queued)
+	}
+	order := make([]int, len(queued))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return queued[order[a]].Priority < queued[order[b]].Priority })
+
+	available := float64(rover.Battery) - float64(p.Reserve)
+	for _, i := range order {
+		if p.Cost(rover, queued[i]) <= available {
+			return i
+		}
+	}
+	return -1
+}
+
+// Cost estimates the battery the rover spends on a mission
+func (p BatteryPolicy) Cost(rover RoverView, mission ml.MissionState) float64 {
+	return CalculateDistance(rover.Position, mission.Coordinate)*p.MovementRate + p.TaskCost
+}
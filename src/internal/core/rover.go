@@ -1,8 +1,13 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"os"
+	"path/filepath"
 	"src/config"
 	"src/internal/devices"
 	"src/internal/ml"
@@ -11,6 +16,7 @@ import (
 	"src/utils/logger"
 	pl "src/utils/packetsLogic"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -27,6 +33,10 @@ type MissionQueue struct {
 	Priority3 []ml.MissionData // Low priority missions
 	Mu        sync.Mutex       // Mutex for queue operations
 	BatchSize uint8            // Number of missions to request at once
+
+	// Mission taken from the queue, recalled by MSG_CANCEL (guarded by Mu)
+	Running       uint16             // ID of the mission dequeued for execution (0 when idle or cancelled before it started)
+	CancelRunning context.CancelFunc // Interrupts the running mission (nil until it started)
 }
 
 // RoverMLState holds the state related to MissionLink connection
@@ -37,24 +47,35 @@ type RoverMLState struct {
 	CondMu              sync.Mutex    // Mutex for the condition
 	Waiting             bool          // Indicates if the rover is waiting for a mission
 	MissionReceivedChan chan bool     // Channel to signal mission reception
+	OpenChan            chan error    // Channel to signal the outcome of the OPEN handshake
+	HelloChan           chan error    // Channel to signal the outcome of the HELLO negotiation
 	SeqNum              uint32        // Sequence number for sending packets
+	SendMu              sync.Mutex    // Orders the SeqNums taken by concurrent senders (windowLock of every send)
 	Suspended           bool          // Indicates if rover is suspended due to low battery
 	SuspendMu           sync.Mutex    // Mutex for suspension state
 	MissionQueue        *MissionQueue // Queue for managing missions by priority
 
 	// Packet and sequence number management
+	SessionOpen bool // Set once ExpectedSeq was seeded by the mothership's OPEN_ACK (guarded by CondMu)
 	ExpectedSeq uint32
 	Buffer      map[uint32]ml.Packet
 	BufferMu    sync.Mutex
 
 	// Sliding window for ACK and retransmission control
-	Window *pl.Window // Sliding window specific to this rover
+	Window *pl.Window      // Sliding window specific to this rover
+	Keys   *ml.SessionKeys // Keys negotiated at registration; the window gets those of each session
+
+	// Store-and-forward of undelivered reports
+	Outbox     *Outbox       // Reports given up on, replayed once the session is re-opened
+	LinkLost   chan struct{} // Signals that a message was given up and the session must be re-opened
+	GiveUps    atomic.Uint64 // Messages given up so far
+	Recovering atomic.Bool   // Set while the session is being re-opened (reports go to the outbox)
 }
 
 // RoverMLConnection holds the UDP connection details for MissionLink
 type RoverMLConnection struct {
-	Conn *net.UDPConn // UDP connection with the mothership
-	Addr *net.UDPAddr // Mothership address
+	Conn pl.PacketConn // UDP connection with the mothership
+	Addr *net.UDPAddr  // Mothership address
 }
 
 // RoverTSState holds the state related to TelemetryLink connection
@@ -83,29 +104,110 @@ type RoverSystem struct {
 	Logger     *logger.Logger     // Logger instance
 }
 
+// ErrIDRefused is returned when the mothership doesn't give back the ID the rover asked for
+var ErrIDRefused = errors.New("mothership refused the requested ID")
+
+// reclaimTokenPath returns the file holding the token that proves the rover holds an ID
+func reclaimTokenPath(roverID uint8) string {
+	return fmt.Sprintf("../outbox/rover_%d/reclaim.token", roverID)
+}
+
+// loadReclaimToken reads the token saved with the last assignment of an ID (zero if there is none)
+func loadReclaimToken(roverID uint8) [ml.ReclaimTokenSize]byte {
+	var token [ml.ReclaimTokenSize]byte
+	if data, err := os.ReadFile(reclaimTokenPath(roverID)); err == nil {
+		copy(token[:], data)
+	}
+	return token
+}
+
+// saveReclaimToken keeps the token of an assignment for the next run of the rover
+func saveReclaimToken(roverID uint8, token [ml.ReclaimTokenSize]byte) error {
+	path := reclaimTokenPath(roverID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, token[:], 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// requestIDWithRetry runs the ID handshake, retrying while a reclaimed ID is refused
+// The mothership refuses an ID whose session still looks alive: after a restart, that takes
+// until the keepalive notices the previous run is gone
+func requestIDWithRetry(mothershipAddr string) (uint8, uint, *ml.SessionKeys, error) {
+	attempts := 1
+	if config.GetRequestedRoverID() != 0 && config.KEEPALIVE_INTERVAL > 0 {
+		attempts = config.KEEPALIVE_MAX_MISSED + 2
+	}
+	for attempt := 1; ; attempt++ {
+		id, updateFrequency, keys, err := requestID(mothershipAddr)
+		if !errors.Is(err, ErrIDRefused) || attempt == attempts {
+			return id, updateFrequency, keys, err
+		}
+		fmt.Printf("⏳ ID %d refused, retrying in %v (%d/%d)\n", config.GetRequestedRoverID(), config.KEEPALIVE_INTERVAL, attempt, attempts-1)
+		time.Sleep(config.KEEPALIVE_INTERVAL)
+	}
+}
+
 // requestID contacts the mothership to request a unique rover ID and update frequency
-func requestID(mothershipAddr string) (uint8, uint, error) {
+// The same exchange negotiates the MissionLink session keys via X25519
+func requestID(mothershipAddr string) (uint8, uint, *ml.SessionKeys, error) {
 	// Make TCP connection to mothership
 	conn, err := net.Dial("tcp", mothershipAddr)
 	if err != nil {
-		return 0, 0, fmt.Errorf("error connecting to ID server: %v", err)
+		return 0, 0, nil, fmt.Errorf("error connecting to ID server: %v", err)
 	}
 	defer conn.Close()
 
-	// Read 2 bytes: 1 for ID and 1 for update frequency
-	buf := make([]byte, 2)
+	// Send our ephemeral public key (and the ID to reclaim, if restarting)
+	privateKey, err := ml.NewHandshakeKey()
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("error generating handshake key: %v", err)
+	}
+	request := ml.IDRequest{RequestedID: config.GetRequestedRoverID()}
+	if request.RequestedID != 0 {
+		request.ReclaimToken = loadReclaimToken(request.RequestedID)
+	}
+	copy(request.PublicKey[:], privateKey.PublicKey().Bytes())
+	if _, err := conn.Write(request.Encode()); err != nil {
+		return 0, 0, nil, fmt.Errorf("error sending public key: %v", err)
+	}
+
+	// Read the ID assignment: ID, update frequency, mothership public key, epoch and encryption flag
+	buf := make([]byte, ml.IDAssignmentSize)
 	conn.SetReadDeadline(time.Now().Add(config.TCP_TIMEOUT))
-	_, err = conn.Read(buf)
+	_, err = io.ReadFull(conn, buf)
 	if err != nil {
-		return 0, 0, fmt.Errorf("timeout or error receiving ID: %v", err)
+		return 0, 0, nil, fmt.Errorf("timeout or error receiving ID: %v", err)
 	}
 
 	// Parse ID and update frequency
-	id := buf[0]
-	updateFrequency := uint(buf[1])
-	fmt.Printf("✅ ID received from mothership: %d (updateFrequency=%d)\n", id, updateFrequency)
+	var assignment ml.IDAssignment
+	if err := assignment.Decode(buf); err != nil {
+		return 0, 0, nil, fmt.Errorf("invalid ID assignment: %v", err)
+	}
+	id := assignment.ID
+	if id == 0 {
+		return 0, 0, nil, ErrIDRefused
+	}
+	updateFrequency := uint(assignment.UpdateFrequency)
+
+	// Derive session keys (encryption follows the mothership's setting)
+	keys, err := ml.DeriveSessionKeys(privateKey, assignment.PublicKey[:], assignment.Epoch, assignment.Encrypted)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("error negotiating session keys: %v", err)
+	}
+	fmt.Printf("✅ ID received from mothership: %d (updateFrequency=%d, encrypted=%v)\n", id, updateFrequency, assignment.Encrypted)
+
+	// Without the token, a restart can't reclaim the ID
+	if err := saveReclaimToken(id, assignment.ReclaimToken); err != nil {
+		fmt.Printf("⚠️ Error saving reclaim token: %v\n", err)
+	}
 
-	return id, updateFrequency, nil
+	return id, updateFrequency, keys, nil
 }
 
 // initConnection initializes the UDP connection to the mothership for MissionLink
@@ -133,7 +235,7 @@ func initConnection(targetAddr string) (*RoverMLConnection, error) {
 // NewRoverSystem creates and initializes a RoverSystem
 func NewRoverSystem(motherUDP string, motherTCPID string) *RoverSystem {
 	// Request ID via TCP
-	roverID, updateFrequency, err := requestID(motherTCPID)
+	roverID, updateFrequency, keys, err := requestIDWithRetry(motherTCPID)
 	if err != nil {
 		fmt.Println("❌ Error obtaining ID:", err)
 		return nil
@@ -156,6 +258,13 @@ func NewRoverSystem(motherUDP string, motherTCPID string) *RoverSystem {
 		return nil
 	}
 
+	// Reports that couldn't be delivered are kept on disk until the link recovers
+	outbox, err := NewOutbox(fmt.Sprintf("../outbox/rover_%d", roverID))
+	if err != nil {
+		fmt.Println("❌ Error opening outbox:", err)
+		return nil
+	}
+
 	log.Infof("Rover", "Rover %d initialized with update frequency %d", roverID, updateFrequency)
 
 	// Return initialized RoverSystem
@@ -172,12 +281,18 @@ func NewRoverSystem(motherUDP string, motherTCPID string) *RoverSystem {
 			ActiveMissions:      0,
 			Cond:                sync.NewCond(&sync.Mutex{}),
 			CondMu:              sync.Mutex{},
+			SeqNum:              0, // Every session starts at a random ISN (see open)
 			ExpectedSeq:         0,
 			Waiting:             false,
 			MissionReceivedChan: make(chan bool, 1),
+			OpenChan:            make(chan error, 1),
+			HelloChan:           make(chan error, 1),
 			Buffer:              make(map[uint32]ml.Packet),
 			BufferMu:            sync.Mutex{},
-			Window:              pl.NewWindow(),
+			Window:              pl.NewWindow(0, keys),
+			Keys:                keys,
+			Outbox:              outbox,
+			LinkLost:            make(chan struct{}, 1),
 			Suspended:           false,
 			SuspendMu:           sync.Mutex{},
 			MissionQueue: &MissionQueue{
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"errors"
+	"src/internal/ml"
+	"src/utils"
+	"sync"
+)
+
+// ErrSchedulerFull is returned when the scheduler holds as many missions as it can
+var ErrSchedulerFull = errors.New("mission queue full")
+
+// RoverView is what a Policy knows about the rover asking for missions
+type RoverView struct {
+	ID           uint8
+	HasTelemetry bool             // Whether Position and Battery come from telemetry
+	Position     utils.Coordinate // Last position reported over telemetry
+	Battery      uint8            // Last battery level reported over telemetry
+}
+
+// Policy decides which queued mission a rover gets next
+type Policy interface {
+	// Name identifies the policy in config.json (SCHEDULER_POLICY)
+	Name() string
+	// Pick returns the index in queued (oldest first) of the mission to hand to rover,
+	// or -1 if none suits it
+	Pick(rover RoverView, queued []ml.MissionState) int
+}
+
+// Scheduler holds the missions waiting for a rover and hands them out following a Policy
+// Missions are kept in arrival order, so every policy can fall back to FIFO
+type Scheduler struct {
+	mu       sync.Mutex
+	queue    []ml.MissionState
+	capacity int
+	policy   Policy
+}
+
+// NewScheduler creates a scheduler holding up to capacity missions
+func NewScheduler(policy Policy, capacity int) *Scheduler {
+	return &Scheduler{
+		queue:    make([]ml.MissionState, 0, capacity),
+		capacity: capacity,
+		policy:   policy,
+	}
+}
+
+// Policy returns the policy in use
+func (s *Scheduler) Policy() Policy {
+	return s.policy
+}
+
+// Push adds a mission to the back of the queue
+func (s *Scheduler) Push(mission ml.MissionState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queue) >= s.capacity {
+		return ErrSchedulerFull
+	}
+	s.queue = append(s.queue, mission)
+	return nil
+}
+
+// Next takes out of the queue the mission the policy picks for rover
+// Returns false if the queue is empty or no mission suits the rover
+func (s *Scheduler) Next(rover RoverView) (ml.MissionState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queue) == 0 {
+		return ml.MissionState{}, false
+	}
+	i := s.policy.Pick(rover, s.queue)
+	if i < 0 || i >= len(s.queue) {
+		return ml.MissionState{}, false
+	}
+	mission := s.queue[i]
+	s.queue = append(s.queue[:i], s.queue[i+1:]...)
+	return mission, true
+}
+
+// Update replaces the queued copy of a mission (after an edit), keeping its place
+// Returns false if the mission is not queued
+func (s *Scheduler) Update(mission ml.MissionState) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.queue {
+		if s.queue[i].ID == mission.ID {
+			s.queue[i] = mission
+			return true
+		}
+	}
+	return false
+}
+
+// Remove takes a mission out of the queue (after a cancellation)
+// Returns false if the mission is not queued
+func (s *Scheduler) Remove(id uint16) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.queue {
+		if s.queue[i].ID == id {
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Free returns how many more missions the scheduler can hold
+func (s *Scheduler) Free() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.capacity - len(s.queue)
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"errors"
+	"src/internal/ml"
+	"src/utils"
+	"testing"
+)
+
+// queue builds queued missions from (priority, latitude) pairs, with IDs from 1 in order
+func queue(missions ...[2]float64) []ml.MissionState {
+	queued := make([]ml.MissionState, len(missions))
+	for i, m := range missions {
+		queued[i] = ml.MissionState{ID: uint16(i + 1), Priority: uint8(m[0]), Coordinate: utils.Coordinate{Latitude: m[1]}}
+	}
+	return queued
+}
+
+func TestPolicies(t *testing.T) {
+	queued := queue([2]float64{3, 0.9}, [2]float64{1, -0.8}, [2]float64{2, 0.1}, [2]float64{1, 0.5})
+	atOrigin := RoverView{ID: 1, HasTelemetry: true, Position: utils.Coordinate{}, Battery: 100}
+	battery := BatteryPolicy{MovementRate: 50, TaskCost: 2, Reserve: 20}
+
+	tests := []struct {
+		name   string
+		policy Policy
+		rover  RoverView
+		want   uint16 // Mission ID picked, 0 for none
+	}{
+		{"fifo", FIFOPolicy{}, atOrigin, 1},
+		{"priority keeps arrival order among equals", PriorityPolicy{}, atOrigin, 2},
+		{"nearest", NearestPolicy{}, atOrigin, 3},
+		{"nearest without telemetry", NearestPolicy{}, RoverView{ID: 1}, 1},
+		{"battery with a full battery", battery, atOrigin, 2},
+		// 40 left over the reserve: mission 4 costs 27, mission 2 costs 42
+		{"battery skips what it can't afford", battery, RoverView{HasTelemetry: true, Battery: 60}, 4},
+		{"battery under the reserve", battery, RoverView{HasTelemetry: true, Battery: 20}, 0},
+		{"battery without telemetry", battery, RoverView{ID: 1}, 2},
+	}
+	for _, tt := range tests {
+		got := uint16(0)
+		if i := tt.policy.Pick(tt.rover, queued); i >= 0 {
+			got = queued[i].ID
+		}
+		if got != tt.want {
+			t.Errorf("%s: picked mission %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewPolicy(t *testing.T) {
+	for _, name := range []string{"fifo", "priority", "nearest", "battery", "Priority"} {
+		if _, err := NewPolicy(name); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if _, err := NewPolicy("random"); err == nil {
+		t.Error("unknown policy accepted")
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	s := NewScheduler(PriorityPolicy{}, 3)
+	for _, m := range queue([2]float64{3, 0}, [2]float64{2, 0}, [2]float64{3, 0}) {
+		if err := s.Push(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Push(ml.MissionState{ID: 4}); !errors.Is(err, ErrSchedulerFull) {
+		t.Fatalf("push over capacity: got %v", err)
+	}
+
+	// Mission 3 edited to priority 1 jumps ahead; mission 2 is cancelled
+	if !s.Update(ml.MissionState{ID: 3, Priority: 1}) || !s.Remove(2) {
+		t.Fatal("queued mission not found")
+	}
+	if s.Update(ml.MissionState{ID: 2}) || s.Remove(2) {
+		t.Fatal("removed mission still queued")
+	}
+	for _, want := range []uint16{3, 1} {
+		if m, ok := s.Next(RoverView{}); !ok || m.ID != want {
+			t.Fatalf("got mission %d (%v), want %d", m.ID, ok, want)
+		}
+	}
+	if _, ok := s.Next(RoverView{}); ok || s.Free() != 3 {
+		t.Fatalf("scheduler not empty: %d free", s.Free())
+	}
+}
@@ -0,0 +1,69 @@
+package ml
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// MAX_SACK_BLOCKS is the maximum number of SACK blocks carried by a single ACK.
+const MAX_SACK_BLOCKS = 8
+
+// SACK_BLOCK_SIZE is the size in bytes of a serialized SackBlock.
+const SACK_BLOCK_SIZE = 8 // 4 (Start) + 4 (End)
+
+// SackBlock describes a contiguous range of sequence numbers [Start, End)
+// already received (and buffered) by the peer beyond the cumulative AckNum.
+type SackBlock struct {
+	Start uint32 // First sequence number of the range
+	End   uint32 // Sequence number right after the range
+}
+
+// ACK_DATA_HEADER_SIZE is the size in bytes of the fixed part of AckData.
+const ACK_DATA_HEADER_SIZE = 3 // 2 (Window) + 1 (SACK block count)
+
+// AckData is the payload of MSG_ACK.
+type AckData struct {
+	Window     uint16      // Receive window: out-of-order packets the receiver can still buffer
+	SackBlocks []SackBlock // Selective acknowledgements (only when CAP_SACK was negotiated)
+}
+
+// Encode serializes the AckData into bytes (BigEndian).
+func (a *AckData) Encode() []byte {
+	blocks := a.SackBlocks
+	if len(blocks) > MAX_SACK_BLOCKS {
+		blocks = blocks[:MAX_SACK_BLOCKS]
+	}
+
+	data := make([]byte, ACK_DATA_HEADER_SIZE+SACK_BLOCK_SIZE*len(blocks))
+	binary.BigEndian.PutUint16(data[0:2], a.Window)
+	data[2] = uint8(len(blocks))
+	idx := ACK_DATA_HEADER_SIZE
+	for _, b := range blocks {
+		binary.BigEndian.PutUint32(data[idx:idx+4], b.Start)
+		binary.BigEndian.PutUint32(data[idx+4:idx+8], b.End)
+		idx += SACK_BLOCK_SIZE
+	}
+	return data
+}
+
+// Decode deserializes bytes into AckData (BigEndian).
+func (a *AckData) Decode(data []byte) error {
+	a.SackBlocks = nil
+	if len(data) < ACK_DATA_HEADER_SIZE {
+		return fmt.Errorf("ack payload too short: %d bytes", len(data))
+	}
+
+	a.Window = binary.BigEndian.Uint16(data[0:2])
+	count := int(data[2])
+	if len(data) < ACK_DATA_HEADER_SIZE+count*SACK_BLOCK_SIZE {
+		return fmt.Errorf("ack payload too short for %d SACK blocks: %d bytes", count, len(data))
+	}
+	a.SackBlocks = make([]SackBlock, count)
+	idx := ACK_DATA_HEADER_SIZE
+	for i := 0; i < count; i++ {
+		a.SackBlocks[i].Start = binary.BigEndian.Uint32(data[idx : idx+4])
+		a.SackBlocks[i].End = binary.BigEndian.Uint32(data[idx+4 : idx+8])
+		idx += SACK_BLOCK_SIZE
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package ml
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// CANCEL_DATA_SIZE is the size in bytes of the CancelData payload.
+const CANCEL_DATA_SIZE = 2 // 2 (MissionID)
+
+// CancelData is the payload of MSG_CANCEL.
+// The mothership recalls a mission it assigned; the rover drops it from its queue, or
+// interrupts it if already running, and answers with a last report flagged as aborted.
+type CancelData struct {
+	MissionID uint16 // Mission to cancel
+}
+
+// Encode serializes the CancelData into bytes (BigEndian).
+func (c *CancelData) Encode() []byte {
+	data := make([]byte, CANCEL_DATA_SIZE)
+	binary.BigEndian.PutUint16(data, c.MissionID)
+	return data
+}
+
+// Decode deserializes bytes into CancelData (BigEndian).
+func (c *CancelData) Decode(data []byte) error {
+	if len(data) < CANCEL_DATA_SIZE {
+		return fmt.Errorf("cancel payload too short: %d bytes", len(data))
+	}
+	c.MissionID = binary.BigEndian.Uint16(data[0:2])
+	return nil
+}
@@ -0,0 +1,154 @@
+package ml
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Seed inputs of every target live in testdata/fuzz/<target>; run one with
+//
+//	go test ./internal/ml -run '^$' -fuzz FuzzPacketDecode
+
+// fuzzCodec checks that decode never panics on arbitrary bytes, and that whatever it accepts
+// encodes into bytes that decode back to the same encoding
+func fuzzCodec[T any](f *testing.F, encode func(*T) []byte, decode func(*T, []byte) error) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		decodeStable(t, data, encode, decode)
+	})
+}
+
+// decodeStable decodes data and, if accepted, checks that its encoding is stable
+func decodeStable[T any](t *testing.T, data []byte, encode func(*T) []byte, decode func(*T, []byte) error) (v T, ok bool) {
+	if decode(&v, data) != nil {
+		return v, false
+	}
+	encoded := encode(&v)
+	var again T
+	if err := decode(&again, encoded); err != nil {
+		t.Fatalf("decoding re-encoded %x: %v", encoded, err)
+	}
+	if reencoded := encode(&again); !bytes.Equal(reencoded, encoded) {
+		t.Fatalf("encoding not stable: %x, then %x", encoded, reencoded)
+	}
+	return v, true
+}
+
+func FuzzPacketDecode(f *testing.F) {
+	fuzzCodec(f, (*Packet).Encode, (*Packet).Decode)
+}
+
+func FuzzMissionDataDecode(f *testing.F) {
+	fuzzCodec(f, (*MissionData).Encode, (*MissionData).Decode)
+}
+
+// FuzzReportDecode also decodes the typed payload, as the mothership does on every report
+func FuzzReportDecode(f *testing.F) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		report, ok := decodeStable(t, data, (*Report).Encode, (*Report).Decode)
+		if ok {
+			report.DecodeTyped()
+		}
+	})
+}
+
+func FuzzImageReportDecode(f *testing.F) {
+	fuzzCodec(f, (*ImageReportData).EncodePayload, (*ImageReportData).DecodePayload)
+}
+
+func FuzzSampleReportDecode(f *testing.F) {
+	fuzzCodec(f, (*SampleReportData).EncodePayload, (*SampleReportData).DecodePayload)
+}
+
+func FuzzEnvReportDecode(f *testing.F) {
+	fuzzCodec(f, (*EnvReportData).EncodePayload, (*EnvReportData).DecodePayload)
+}
+
+func FuzzRepairReportDecode(f *testing.F) {
+	fuzzCodec(f, (*RepairReportData).EncodePayload, (*RepairReportData).DecodePayload)
+}
+
+func FuzzTopoReportDecode(f *testing.F) {
+	fuzzCodec(f, (*TopoReportData).EncodePayload, (*TopoReportData).DecodePayload)
+}
+
+func FuzzInstallReportDecode(f *testing.F) {
+	fuzzCodec(f, (*InstallReportData).EncodePayload, (*InstallReportData).DecodePayload)
+}
+
+func FuzzHelloDataDecode(f *testing.F) {
+	fuzzCodec(f, (*HelloData).Encode, (*HelloData).Decode)
+}
+
+func FuzzErrorDataDecode(f *testing.F) {
+	fuzzCodec(f, (*ErrorData).Encode, (*ErrorData).Decode)
+}
+
+func FuzzOpenDataDecode(f *testing.F) {
+	fuzzCodec(f, (*OpenData).Encode, (*OpenData).Decode)
+}
+
+func FuzzPathDataDecode(f *testing.F) {
+	fuzzCodec(f, (*PathData).Encode, (*PathData).Decode)
+}
+
+func FuzzPingDataDecode(f *testing.F) {
+	fuzzCodec(f, (*PingData).Encode, (*PingData).Decode)
+}
+
+func FuzzCancelDataDecode(f *testing.F) {
+	fuzzCodec(f, (*CancelData).Encode, (*CancelData).Decode)
+}
+
+func FuzzIDRequestDecode(f *testing.F) {
+	fuzzCodec(f, (*IDRequest).Encode, (*IDRequest).Decode)
+}
+
+func FuzzIDAssignmentDecode(f *testing.F) {
+	fuzzCodec(f, (*IDAssignment).Encode, (*IDAssignment).Decode)
+}
+
+func FuzzAckDataDecode(f *testing.F) {
+	fuzzCodec(f, (*AckData).Encode, (*AckData).Decode)
+}
+
+// FuzzFecDataDecode also recovers every entry from an empty group, as a receiver could
+// with a forged parity packet
+func FuzzFecDataDecode(f *testing.F) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		d, ok := decodeStable(t, data, (*FecData).Encode, (*FecData).Decode)
+		if !ok {
+			return
+		}
+		payloads := make(map[uint32][]byte)
+		for _, entry := range d.Entries {
+			payloads[entry.SeqNum] = make([]byte, entry.Length)
+		}
+		for lost := range d.Entries {
+			if p, err := d.Recover(lost, payloads); err == nil && len(p.Payload) != int(d.Entries[lost].Length) {
+				t.Fatalf("entry %d recovered with %d bytes, want %d", lost, len(p.Payload), d.Entries[lost].Length)
+			}
+		}
+	})
+}
+
+func FuzzFragmentHeaderDecode(f *testing.F) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var header FragmentHeader
+		fragment, err := header.Decode(data)
+		if err != nil {
+			return
+		}
+		if encoded := header.Encode(fragment); !bytes.Equal(encoded, data) {
+			t.Fatalf("fragment %x encoded as %x", data, encoded)
+		}
+	})
+}
+
+func FuzzFlateDecompress(f *testing.F) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		out, err := FlateCodec{}.Decompress(data)
+		if err == nil && len(out) > MAX_DECOMPRESSED_SIZE {
+			t.Fatalf("decompressed %d bytes", len(out))
+		}
+	})
+}
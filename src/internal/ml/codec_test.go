@@ -0,0 +1,201 @@
+package ml
+
+import (
+	"bytes"
+	"compress/flate"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+// roundTrip checks, over random values, that decoding what encode produced gives the value back
+// normalize brings a random value into the range representable on the wire
+func roundTrip[T any](t *testing.T, normalize func(*T), encode func(*T) []byte, decode func(*T, []byte) error) {
+	t.Helper()
+	check := func(v T) bool {
+		normalize(&v)
+		var got T
+		if err := decode(&got, encode(&v)); err != nil {
+			t.Logf("decode %+v: %v", v, err)
+			return false
+		}
+		if !reflect.DeepEqual(v, got) {
+			t.Logf("sent %+v, decoded %+v", v, got)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(check, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func same[T any](*T) {}
+
+func TestPacketRoundTrip(t *testing.T) {
+	roundTrip(t, func(p *Packet) {
+		p.Version &= 0x0F
+		p.Flags &= 0x0F
+		if len(p.Payload) == 0 {
+			p.Payload = nil
+		}
+	}, (*Packet).Encode, (*Packet).Decode)
+}
+
+func TestMissionDataRoundTrip(t *testing.T) {
+	roundTrip(t, func(d *MissionData) {
+		d.TaskType &= 0x0F
+		d.Priority &= 0x0F
+	}, (*MissionData).Encode, (*MissionData).Decode)
+}
+
+func TestReportRoundTrip(t *testing.T) {
+	roundTrip(t, same[Report], (*Report).Encode, (*Report).Decode)
+}
+
+func TestReportPayloadsRoundTrip(t *testing.T) {
+	roundTrip(t, same[ImageReportData], (*ImageReportData).EncodePayload, (*ImageReportData).DecodePayload)
+	roundTrip(t, same[SampleReportData], (*SampleReportData).EncodePayload, (*SampleReportData).DecodePayload)
+	roundTrip(t, same[EnvReportData], (*EnvReportData).EncodePayload, (*EnvReportData).DecodePayload)
+	roundTrip(t, same[RepairReportData], (*RepairReportData).EncodePayload, (*RepairReportData).DecodePayload)
+	roundTrip(t, same[TopoReportData], (*TopoReportData).EncodePayload, (*TopoReportData).DecodePayload)
+	roundTrip(t, same[InstallReportData], (*InstallReportData).EncodePayload, (*InstallReportData).DecodePayload)
+}
+
+func TestReportDecodeTyped(t *testing.T) {
+	payloads := map[uint8]PayloadEncoder{
+		TASK_IMAGE_CAPTURE:     &ImageReportData{ChunkID: 7, Data: []byte("chunk")},
+		TASK_SAMPLE_COLLECTION: &SampleReportData{Components: []Component{{Name: "Fe", Percentage: 12.5}, {Name: "Si", Percentage: 40}}},
+		TASK_ENV_ANALYSIS:      &EnvReportData{Temp: -63, Oxygen: 0.13, Pressure: 6.1, Humidity: 3, WindSpeed: 7.5, Radiation: 0.67},
+		TASK_REPAIR_RESCUE:     &RepairReportData{ProblemID: 3, Repairable: true},
+		TASK_TOPO_MAPPING:      &TopoReportData{Latitude: 41.545, Longitude: -8.421, Height: 120.5},
+		TASK_INSTALLATION:      &InstallReportData{Success: true},
+	}
+	for taskType, payload := range payloads {
+		var report Report
+		if err := report.Decode(NewReport(taskType, 1, true, payload).Encode()); err != nil {
+			t.Fatalf("task %d: %v", taskType, err)
+		}
+		got, err := report.DecodeTyped()
+		if err != nil {
+			t.Fatalf("task %d: %v", taskType, err)
+		}
+		if want := reflect.ValueOf(payload).Elem().Interface(); !reflect.DeepEqual(got, want) {
+			t.Errorf("task %d: decoded %+v, want %+v", taskType, got, want)
+		}
+	}
+}
+
+func TestControlPayloadsRoundTrip(t *testing.T) {
+	roundTrip(t, same[HelloData], (*HelloData).Encode, (*HelloData).Decode)
+	roundTrip(t, same[ErrorData], (*ErrorData).Encode, (*ErrorData).Decode)
+	roundTrip(t, same[OpenData], (*OpenData).Encode, (*OpenData).Decode)
+	roundTrip(t, same[PathData], (*PathData).Encode, (*PathData).Decode)
+	roundTrip(t, same[PingData], (*PingData).Encode, (*PingData).Decode)
+	roundTrip(t, same[CancelData], (*CancelData).Encode, (*CancelData).Decode)
+	roundTrip(t, same[IDRequest], (*IDRequest).Encode, (*IDRequest).Decode)
+	roundTrip(t, same[IDAssignment], (*IDAssignment).Encode, (*IDAssignment).Decode)
+}
+
+func TestAckDataRoundTrip(t *testing.T) {
+	roundTrip(t, func(a *AckData) {
+		if len(a.SackBlocks) > MAX_SACK_BLOCKS {
+			a.SackBlocks = a.SackBlocks[:MAX_SACK_BLOCKS]
+		}
+	}, (*AckData).Encode, (*AckData).Decode)
+}
+
+func TestFecDataRoundTrip(t *testing.T) {
+	roundTrip(t, func(d *FecData) {
+		if len(d.Entries) == 0 {
+			d.Entries = []FecEntry{{}}
+		}
+		if len(d.Entries) > MAX_FEC_GROUP {
+			d.Entries = d.Entries[:MAX_FEC_GROUP]
+		}
+		if len(d.Parity) == 0 {
+			d.Parity = nil
+		}
+		for i := range d.Entries {
+			d.Entries[i].Length %= uint16(len(d.Parity) + 1)
+		}
+	}, (*FecData).Encode, (*FecData).Decode)
+}
+
+func TestFragmentHeaderRoundTrip(t *testing.T) {
+	check := func(f FragmentHeader, data []byte) bool {
+		f.Total = f.Total%MAX_FRAGMENTS + 1
+		f.Index %= f.Total
+		var got FragmentHeader
+		rest, err := got.Decode(f.Encode(data))
+		return err == nil && got == f && bytes.Equal(rest, data)
+	}
+	if err := quick.Check(check, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFlateCodecRoundTrip(t *testing.T) {
+	codec := FlateCodec{Level: flate.BestSpeed}
+	check := func(data []byte) bool {
+		compressed, err := codec.Compress(data)
+		if err != nil {
+			return false
+		}
+		got, err := codec.Decompress(compressed)
+		return err == nil && bytes.Equal(got, data)
+	}
+	if err := quick.Check(check, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestDecodersRejectTruncatedInput feeds every prefix of a valid encoding to each decoder:
+// the ones shorter than the fixed part must fail (and none may panic)
+func TestDecodersRejectTruncatedInput(t *testing.T) {
+	sample := SampleReportData{Components: []Component{{Name: "Fe", Percentage: 12.5}, {Name: "Si", Percentage: 40}}}
+	fec := NewFecData([]Packet{{SeqNum: 1, Payload: []byte("abc")}, {SeqNum: 4, Payload: []byte("de")}})
+	ack := AckData{Window: 16, SackBlocks: []SackBlock{{Start: 10, End: 20}}}
+	fragment := FragmentHeader{MessageID: 1, Index: 0, Total: 2}
+
+	decoders := []struct {
+		name    string
+		encoded []byte
+		minSize int // Shortest prefix that decodes
+		decode  func([]byte) error
+	}{
+		{"Packet", (&Packet{MsgType: MSG_REPORT, Payload: []byte("x")}).Encode(), PacketHeaderSize, func(b []byte) error { return new(Packet).Decode(b) }},
+		{"MissionData", (&MissionData{MsgID: 1}).Encode(), MissionDataSize, func(b []byte) error { return new(MissionData).Decode(b) }},
+		{"Report", (&Report{Payload: []byte("x")}).Encode(), REPORT_HEADER_SIZE, func(b []byte) error { return new(Report).Decode(b) }},
+		{"ImageReportData", (&ImageReportData{ChunkID: 1, Data: []byte("x")}).EncodePayload(), 2, func(b []byte) error { return new(ImageReportData).DecodePayload(b) }},
+		{"SampleReportData", sample.EncodePayload(), len(sample.EncodePayload()), func(b []byte) error { return new(SampleReportData).DecodePayload(b) }},
+		{"EnvReportData", (&EnvReportData{}).EncodePayload(), 24, func(b []byte) error { return new(EnvReportData).DecodePayload(b) }},
+		{"RepairReportData", (&RepairReportData{}).EncodePayload(), 2, func(b []byte) error { return new(RepairReportData).DecodePayload(b) }},
+		{"TopoReportData", (&TopoReportData{}).EncodePayload(), 20, func(b []byte) error { return new(TopoReportData).DecodePayload(b) }},
+		{"InstallReportData", (&InstallReportData{}).EncodePayload(), 1, func(b []byte) error { return new(InstallReportData).DecodePayload(b) }},
+		{"HelloData", (&HelloData{}).Encode(), HELLO_DATA_SIZE, func(b []byte) error { return new(HelloData).Decode(b) }},
+		{"ErrorData", (&ErrorData{Message: "x"}).Encode(), 1, func(b []byte) error { return new(ErrorData).Decode(b) }},
+		{"OpenData", (&OpenData{}).Encode(), OPEN_DATA_SIZE, func(b []byte) error { return new(OpenData).Decode(b) }},
+		{"PathData", (&PathData{}).Encode(), PATH_TOKEN_SIZE, func(b []byte) error { return new(PathData).Decode(b) }},
+		{"PingData", (&PingData{}).Encode(), PING_DATA_SIZE, func(b []byte) error { return new(PingData).Decode(b) }},
+		{"CancelData", (&CancelData{}).Encode(), CANCEL_DATA_SIZE, func(b []byte) error { return new(CancelData).Decode(b) }},
+		{"IDRequest", (&IDRequest{}).Encode(), IDRequestSize, func(b []byte) error { return new(IDRequest).Decode(b) }},
+		{"IDAssignment", (&IDAssignment{}).Encode(), IDAssignmentSize, func(b []byte) error { return new(IDAssignment).Decode(b) }},
+		{"AckData", ack.Encode(), len(ack.Encode()), func(b []byte) error { return new(AckData).Decode(b) }},
+		{"FecData", fec.Encode(), len(fec.Encode()), func(b []byte) error { return new(FecData).Decode(b) }},
+		{"FragmentHeader", fragment.Encode([]byte("x")), FRAGMENT_HEADER_SIZE, func(b []byte) error { _, err := new(FragmentHeader).Decode(b); return err }},
+	}
+	for _, d := range decoders {
+		t.Run(d.name, func(t *testing.T) {
+			for n := 0; n <= len(d.encoded); n++ {
+				err := d.decode(d.encoded[:n])
+				if n < d.minSize && err == nil {
+					t.Errorf("%d of %d bytes decoded without error", n, len(d.encoded))
+				}
+				if n >= d.minSize && err != nil {
+					t.Errorf("%d of %d bytes: %v", n, len(d.encoded), err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package ml
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+)
+
+// MAX_DECOMPRESSED_SIZE bounds the size of a decompressed payload, so a small malicious
+// packet can't expand into an arbitrarily large message.
+const MAX_DECOMPRESSED_SIZE = 1 << 20
+
+// Codec compresses the payloads of packets flagged with FLAG_COMPRESSED.
+// Both peers must use the same codec; it is agreed upon with CAP_COMPRESSION.
+type Codec interface {
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// FlateCodec is the default Codec, raw DEFLATE (RFC 1951) from compress/flate.
+type FlateCodec struct {
+	Level int // flate.BestSpeed .. flate.BestCompression
+}
+
+// Compress deflates data.
+func (c FlateCodec) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, c.Level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress inflates data, refusing results larger than MAX_DECOMPRESSED_SIZE.
+func (c FlateCodec) Decompress(data []byte) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(data))
+	defer r.Close()
+
+	out, err := io.ReadAll(io.LimitReader(r, MAX_DECOMPRESSED_SIZE+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(out) > MAX_DECOMPRESSED_SIZE {
+		return nil, fmt.Errorf("decompressed payload exceeds %d bytes", MAX_DECOMPRESSED_SIZE)
+	}
+	return out, nil
+}
@@ -0,0 +1,147 @@
+package ml
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// MAX_FEC_GROUP is the largest number of packets protected by one parity packet.
+const MAX_FEC_GROUP = 16
+
+// FEC_ENTRY_SIZE is the size in bytes of each FecEntry in a FecData payload.
+const FEC_ENTRY_SIZE = 12
+
+// FecEntry describes one packet protected by a parity packet: everything needed to
+// rebuild its header once the payload is recovered.
+type FecEntry struct {
+	SeqNum  uint32
+	AckNum  uint32
+	Length  uint16 // Payload length on the wire
+	MsgType PacketType
+	Flags   uint8
+}
+
+// FecData is the payload of MSG_FEC: the XOR of the wire payloads (zero-padded to the
+// longest one) of a group of sequenced packets.
+// The receiver rebuilds a single lost packet of the group by XORing the parity with the
+// payloads it did receive, so the loss is repaired without waiting for a retransmission.
+// Payloads are protected as sent (sealed when the session is encrypted): a rebuilt packet
+// goes through the same decryption and authentication as any other.
+type FecData struct {
+	Entries []FecEntry
+	Parity  []byte
+}
+
+// FecDataSize returns the size of the FecData protecting count packets whose longest
+// payload is maxLength bytes.
+func FecDataSize(count int, maxLength int) int {
+	return 1 + count*FEC_ENTRY_SIZE + maxLength
+}
+
+// NewFecData computes the parity of a group of packets (as sent, after sealing).
+func NewFecData(group []Packet) FecData {
+	d := FecData{Entries: make([]FecEntry, len(group))}
+	for i, p := range group {
+		d.Entries[i] = FecEntry{
+			SeqNum:  p.SeqNum,
+			AckNum:  p.AckNum,
+			Length:  uint16(len(p.Payload)),
+			MsgType: p.MsgType,
+			Flags:   p.Flags,
+		}
+		if len(p.Payload) > len(d.Parity) {
+			d.Parity = append(d.Parity, make([]byte, len(p.Payload)-len(d.Parity))...)
+		}
+		xorInto(d.Parity, p.Payload)
+	}
+	return d
+}
+
+// Recover rebuilds the packet entries[lost] from the payloads of every other packet of
+// the group. The header fields not covered by the entry (version, rover) are left to the caller.
+func (d *FecData) Recover(lost int, payloads map[uint32][]byte) (Packet, error) {
+	if lost < 0 || lost >= len(d.Entries) {
+		return Packet{}, fmt.Errorf("fec entry %d out of range", lost)
+	}
+
+	payload := make([]byte, len(d.Parity))
+	copy(payload, d.Parity)
+	for i, entry := range d.Entries {
+		if i == lost {
+			continue
+		}
+		known, ok := payloads[entry.SeqNum]
+		if !ok || len(known) != int(entry.Length) {
+			return Packet{}, fmt.Errorf("fec group misses packet %d", entry.SeqNum)
+		}
+		xorInto(payload, known)
+	}
+
+	entry := d.Entries[lost]
+	return Packet{
+		Flags:   entry.Flags,
+		MsgType: entry.MsgType,
+		SeqNum:  entry.SeqNum,
+		AckNum:  entry.AckNum,
+		Payload: payload[:entry.Length],
+	}, nil
+}
+
+// Encode serializes the FecData into bytes.
+func (d *FecData) Encode() []byte {
+	data := make([]byte, FecDataSize(len(d.Entries), len(d.Parity)))
+	data[0] = uint8(len(d.Entries))
+	offset := 1
+	for _, entry := range d.Entries {
+		binary.BigEndian.PutUint32(data[offset:offset+4], entry.SeqNum)
+		binary.BigEndian.PutUint32(data[offset+4:offset+8], entry.AckNum)
+		binary.BigEndian.PutUint16(data[offset+8:offset+10], entry.Length)
+		data[offset+10] = uint8(entry.MsgType)
+		data[offset+11] = entry.Flags
+		offset += FEC_ENTRY_SIZE
+	}
+	copy(data[offset:], d.Parity)
+	return data
+}
+
+// Decode deserializes bytes into FecData.
+func (d *FecData) Decode(data []byte) error {
+	if len(data) < 1 {
+		return fmt.Errorf("fec payload too short: %d bytes", len(data))
+	}
+	count := int(data[0])
+	if count == 0 || count > MAX_FEC_GROUP {
+		return fmt.Errorf("invalid fec group size: %d", count)
+	}
+	if len(data) < FecDataSize(count, 0) {
+		return fmt.Errorf("fec payload too short: %d bytes for %d packets", len(data), count)
+	}
+
+	d.Entries = make([]FecEntry, count)
+	offset := 1
+	for i := range d.Entries {
+		d.Entries[i] = FecEntry{
+			SeqNum:  binary.BigEndian.Uint32(data[offset : offset+4]),
+			AckNum:  binary.BigEndian.Uint32(data[offset+4 : offset+8]),
+			Length:  binary.BigEndian.Uint16(data[offset+8 : offset+10]),
+			MsgType: PacketType(data[offset+10]),
+			Flags:   data[offset+11],
+		}
+		offset += FEC_ENTRY_SIZE
+	}
+	d.Parity = append([]byte(nil), data[offset:]...)
+
+	for _, entry := range d.Entries {
+		if int(entry.Length) > len(d.Parity) {
+			return fmt.Errorf("fec parity shorter than packet %d", entry.SeqNum)
+		}
+	}
+	return nil
+}
+
+// xorInto XORs src into dst (len(dst) >= len(src)).
+func xorInto(dst, src []byte) {
+	for i := range src {
+		dst[i] ^= src[i]
+	}
+}
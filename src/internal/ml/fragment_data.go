@@ -0,0 +1,45 @@
+package ml
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// FRAGMENT_HEADER_SIZE is the size in bytes of the FragmentHeader prepended to each fragment.
+const FRAGMENT_HEADER_SIZE = 6 // 2 (MessageID) + 2 (Index) + 2 (Total)
+
+// MAX_FRAGMENTS bounds how many fragments a single message may be split into.
+const MAX_FRAGMENTS = 1024
+
+// FragmentHeader starts the payload of every packet flagged with FLAG_FRAGMENT.
+// All fragments of a message share its MessageID and MsgType; the receiver
+// rebuilds the original payload by concatenating fragments 0..Total-1.
+type FragmentHeader struct {
+	MessageID uint16 // Identifies the fragmented message (per sender)
+	Index     uint16 // Position of this fragment [0, Total)
+	Total     uint16 // Number of fragments of the message
+}
+
+// Encode serializes the FragmentHeader followed by the fragment data.
+func (f *FragmentHeader) Encode(data []byte) []byte {
+	out := make([]byte, FRAGMENT_HEADER_SIZE+len(data))
+	binary.BigEndian.PutUint16(out[0:2], f.MessageID)
+	binary.BigEndian.PutUint16(out[2:4], f.Index)
+	binary.BigEndian.PutUint16(out[4:6], f.Total)
+	copy(out[FRAGMENT_HEADER_SIZE:], data)
+	return out
+}
+
+// Decode deserializes a fragment payload into its header and returns the fragment data.
+func (f *FragmentHeader) Decode(payload []byte) ([]byte, error) {
+	if len(payload) < FRAGMENT_HEADER_SIZE {
+		return nil, fmt.Errorf("fragment payload too short: %d bytes", len(payload))
+	}
+	f.MessageID = binary.BigEndian.Uint16(payload[0:2])
+	f.Index = binary.BigEndian.Uint16(payload[2:4])
+	f.Total = binary.BigEndian.Uint16(payload[4:6])
+	if f.Total == 0 || f.Total > MAX_FRAGMENTS || f.Index >= f.Total {
+		return nil, fmt.Errorf("invalid fragment %d/%d", f.Index, f.Total)
+	}
+	return payload[FRAGMENT_HEADER_SIZE:], nil
+}
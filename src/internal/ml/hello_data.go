@@ -0,0 +1,92 @@
+package ml
+
+import "fmt"
+
+// Capabilities advertised in MSG_HELLO / MSG_HELLO_ACK (bitmask).
+const (
+	CAP_SACK          uint8 = 1 << iota // Selective acknowledgements
+	CAP_COMPRESSION                     // Payload compression
+	CAP_ENCRYPTION                      // Payload encryption
+	CAP_FRAGMENTATION                   // Fragmentation of messages larger than the MTU
+	CAP_KEEPALIVE                       // MSG_PING / MSG_PONG liveness probes
+	CAP_FEC                             // MSG_FEC parity of report streams
+)
+
+// SUPPORTED_CAPABILITIES lists the optional features implemented by this build.
+// CAP_ENCRYPTION is advertised separately, only when the session has an AEAD, and
+// CAP_COMPRESSION and CAP_FEC only when enabled in the configuration.
+const SUPPORTED_CAPABILITIES = CAP_SACK | CAP_FRAGMENTATION | CAP_KEEPALIVE
+
+// Error codes carried by MSG_ERROR.
+const (
+	ERR_UNSUPPORTED_VERSION uint8 = iota + 1
+)
+
+// HELLO_DATA_SIZE is the size in bytes of the HelloData payload.
+const HELLO_DATA_SIZE = 2 // 1 (Version) + 1 (Capabilities)
+
+// HelloData is the payload of MSG_HELLO and MSG_HELLO_ACK.
+// In a HELLO it carries the rover's capabilities; in the HELLO_ACK the negotiated set.
+type HelloData struct {
+	Version      uint8 // Protocol version spoken by the sender
+	Capabilities uint8 // Capability bitmask
+}
+
+// Encode serializes the HelloData into bytes.
+func (h *HelloData) Encode() []byte {
+	return []byte{h.Version, h.Capabilities}
+}
+
+// Decode deserializes bytes into HelloData.
+func (h *HelloData) Decode(data []byte) error {
+	if len(data) < HELLO_DATA_SIZE {
+		return fmt.Errorf("hello payload too short: %d bytes", len(data))
+	}
+	h.Version = data[0]
+	h.Capabilities = data[1]
+	return nil
+}
+
+// CapabilitiesString returns a human-readable list of capabilities.
+func CapabilitiesString(caps uint8) string {
+	names := ""
+	for _, c := range []struct {
+		bit  uint8
+		name string
+	}{{CAP_SACK, "SACK"}, {CAP_COMPRESSION, "COMPRESSION"}, {CAP_ENCRYPTION, "ENCRYPTION"}, {CAP_FRAGMENTATION, "FRAGMENTATION"}, {CAP_KEEPALIVE, "KEEPALIVE"}, {CAP_FEC, "FEC"}} {
+		if caps&c.bit != 0 {
+			if names != "" {
+				names += ","
+			}
+			names += c.name
+		}
+	}
+	if names == "" {
+		return "none"
+	}
+	return names
+}
+
+// ErrorData is the payload of MSG_ERROR.
+type ErrorData struct {
+	Code    uint8  // Error code (ERR_*)
+	Message string // Human-readable description
+}
+
+// Encode serializes the ErrorData into bytes.
+func (e *ErrorData) Encode() []byte {
+	data := make([]byte, 1+len(e.Message))
+	data[0] = e.Code
+	copy(data[1:], e.Message)
+	return data
+}
+
+// Decode deserializes bytes into ErrorData.
+func (e *ErrorData) Decode(data []byte) error {
+	if len(data) < 1 {
+		return fmt.Errorf("error payload is empty")
+	}
+	e.Code = data[0]
+	e.Message = string(data[1:])
+	return nil
+}
@@ -2,6 +2,7 @@ package ml
 
 import (
 	"encoding/binary"
+	"fmt"
 	"src/utils"
 	"math"
 )
@@ -35,8 +36,11 @@ func (d *MissionData) Encode() []byte {
 }
 
 // Decode desserialize the bytes into Data (BigEndian). This function expects the same order used in Encode.
-func (d *MissionData) Decode(data []byte) MissionData {
-    return MissionData{
+func (d *MissionData) Decode(data []byte) error {
+    if len(data) < MissionDataSize {
+        return fmt.Errorf("mission payload too short: %d bytes", len(data))
+    }
+    *d = MissionData{
         MsgID: binary.BigEndian.Uint16(data[0:]),
         Coordinate: utils.Coordinate{
             Latitude:  math.Float64frombits(binary.BigEndian.Uint64(data[2:])),
@@ -48,5 +52,6 @@ func (d *MissionData) Decode(data []byte) MissionData {
         Duration:        binary.BigEndian.Uint32(data[19:]),
         UpdateFrequency: binary.BigEndian.Uint32(data[23:]),
     }
+    return nil
 }
 
@@ -1,7 +1,9 @@
 package ml
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"sort"
 	"src/utils"
 	"sync"
@@ -18,14 +20,63 @@ type MissionState struct {
 	LastUpdate      time.Time        `json:"lastUpdate"`      // Time of the last update
 	CreatedAt       time.Time        `json:"createdAt"`       // Time when the mission was created
 	Priority        uint8            `json:"priority"`        // Priority level of the mission
-	Report          []Report         `json:"reports"`         // Reports related to the mission
-	State           string           `json:"state"`           // e.g, "Pending", "Moving to", "In Progress", "Completed"
+	Report          []Report         `json:"reports"`         // Reports related to the mission, ordered by report number
+	MissingReports  []uint16         `json:"missingReports"`  // Report numbers skipped by the ones received so far
+	State           string           `json:"state"`           // e.g, "Queued", "Pending", "In Progress", "Completed", "Cancelled"
 	Coordinate      utils.Coordinate `json:"coordinate"`      // Target coordinate for the mission
+
+	PreviousAttempts []MissionAttempt `json:"previousAttempts"` // Reports of the rovers the mission was taken from
+}
+
+// MissionAttempt keeps the reports a rover sent for a mission before it was reassigned.
+// Every rover numbers its reports from 0, so they can't share Report with the next attempt.
+type MissionAttempt struct {
+	IDRover        uint8    `json:"idRover"`        // Rover the mission was taken from
+	Report         []Report `json:"reports"`        // Reports received from it, ordered by report number
+	MissingReports []uint16 `json:"missingReports"` // Report numbers it skipped
+}
+
+// Priorities accepted for a mission, one per rover queue (1 is the most urgent)
+const (
+	MIN_PRIORITY = 1
+	MAX_PRIORITY = 3
+)
+
+// Errors returned by the MissionManager.
+var (
+	ErrMissionNotFound     = errors.New("mission not found")
+	ErrMissionFinished     = errors.New("mission already finished")
+	ErrMissionNotQueued    = errors.New("mission no longer queued")
+	ErrMissionNotAssigned  = errors.New("mission not assigned to the rover")
+	ErrMissionReassigned   = errors.New("mission reassigned, report kept with the rover's earlier attempt")
+	ErrMissionIDsExhausted = errors.New("mission IDs exhausted")
+)
+
+// Validate checks that the mission can be sent to a rover and carried out.
+// Duration and UpdateFrequency are in seconds, as in missions.json.
+func (m *MissionState) Validate() error {
+	if m.TaskType > TASK_INSTALLATION {
+		return fmt.Errorf("unknown task type %d (expected %d-%d)", m.TaskType, TASK_IMAGE_CAPTURE, TASK_INSTALLATION)
+	}
+	if m.Priority < MIN_PRIORITY || m.Priority > MAX_PRIORITY {
+		return fmt.Errorf("priority %d out of range (expected %d-%d)", m.Priority, MIN_PRIORITY, MAX_PRIORITY)
+	}
+	if !m.Coordinate.InMap() {
+		return fmt.Errorf("coordinate %s outside the map [%g, %g]", m.Coordinate, utils.MAP_MIN, utils.MAP_MAX)
+	}
+	if m.Duration < 0 || m.Duration > math.MaxUint32 {
+		return fmt.Errorf("duration %d out of range", m.Duration)
+	}
+	if m.UpdateFrequency < 0 || m.UpdateFrequency > math.MaxUint32 {
+		return fmt.Errorf("update frequency %d out of range", m.UpdateFrequency)
+	}
+	return nil
 }
 
 // MissionManager will manage all the active missions.
 type MissionManager struct {
 	ActiveMissions map[uint16]*MissionState
+	lastID         uint16 // Last mission ID handed out by NewMissionID
 	mu             sync.RWMutex
 }
 
@@ -36,6 +87,18 @@ func NewMissionManager() *MissionManager {
 	}
 }
 
+// NewMissionID allocates the next mission ID. IDs start from 1 and are never reused,
+// so a cancelled or deleted mission can't be confused with a newer one.
+func (mm *MissionManager) NewMissionID() (uint16, error) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	if mm.lastID == math.MaxUint16 {
+		return 0, ErrMissionIDsExhausted
+	}
+	mm.lastID++
+	return mm.lastID, nil
+}
+
 // AddMission adds a new mission to the manager.
 func (mm *MissionManager) AddMission(mission *MissionState) {
 	mm.mu.Lock()
@@ -44,25 +107,86 @@ func (mm *MissionManager) AddMission(mission *MissionState) {
 }
 
 // UpdateMission updates the mission state based on a report.
-func UpdateMission(mm *MissionManager, report Report) {
+// Reports are kept ordered by report number and ingested only once, so retransmitted or
+// replayed reports are ignored. A mission is completed once its last report and every
+// report before it were received. Cancelled missions keep their state, but the reports
+// still in flight when they were cancelled are kept.
+// Only the rover the mission is assigned to can report on it. Late reports of a rover the
+// mission was taken from (e.g. replayed after it restarted) go to its attempt in
+// PreviousAttempts and fail with ErrMissionReassigned; without one they fail with
+// ErrMissionNotAssigned.
+// Returns whether the report was new and the report numbers still missing.
+func UpdateMission(mm *MissionManager, roverID uint8, report Report) (bool, []uint16, error) {
 	mm.mu.Lock()
 	defer mm.mu.Unlock()
 
 	mission := mm.ActiveMissions[report.GetMissionID()]
 	if mission == nil {
-		return
+		return false, nil, ErrMissionNotFound
+	}
+	if mission.IDRover != roverID {
+		for i := len(mission.PreviousAttempts) - 1; i >= 0; i-- {
+			attempt := &mission.PreviousAttempts[i]
+			if attempt.IDRover != roverID {
+				continue
+			}
+			added := insertReport(&attempt.Report, report)
+			if added {
+				attempt.MissingReports = missingReports(attempt.Report)
+			}
+			return added, append([]uint16(nil), attempt.MissingReports...), ErrMissionReassigned
+		}
+		return false, nil, ErrMissionNotAssigned
+	}
+
+	if !insertReport(&mission.Report, report) {
+		return false, append([]uint16(nil), mission.MissingReports...), nil
 	}
 
 	// Actualize generic state
-	mission.Report = append(mission.Report, report)
+	mission.MissingReports = missingReports(mission.Report)
 	mission.LastUpdate = time.Now()
 
-	// Actualize state based on the report
-	if report.IsLast() {
+	// Actualize state based on the reports
+	switch {
+	case mission.State == "Cancelled":
+		// Reports sent before the rover got the cancellation don't revive the mission
+	case mission.Report[len(mission.Report)-1].IsLast() && len(mission.MissingReports) == 0:
 		mission.State = "Completed"
-	} else {
+	default:
 		mission.State = "In Progress"
 	}
+	return true, append([]uint16(nil), mission.MissingReports...), nil
+}
+
+// insertReport adds report to reports (ordered by report number), unless one with its
+// number is already there. Returns whether it was added
+func insertReport(reports *[]Report, report Report) bool {
+	seq := report.Header.Seq
+	list := *reports
+	i := sort.Search(len(list), func(i int) bool { return list[i].Header.Seq >= seq })
+	if i < len(list) && list[i].Header.Seq == seq {
+		return false
+	}
+	list = append(list, Report{})
+	copy(list[i+1:], list[i:])
+	list[i] = report
+	*reports = list
+	return true
+}
+
+// missingReports returns the report numbers absent from reports (ordered by report number)
+// up to the highest one received
+func missingReports(reports []Report) []uint16 {
+	missing := []uint16{}
+	next := 0
+	for _, rep := range reports {
+		for seq := next; seq < int(rep.Header.Seq); seq++ {
+			missing = append(missing, uint16(seq))
+		}
+		next = int(rep.Header.Seq) + 1
+	}
+	return missing
 }
 
 // UpdateMissionState actualize the state of a mission.
@@ -79,6 +203,93 @@ func (mm *MissionManager) UpdateMissionState(missionID uint16, newState string)
 	mission.LastUpdate = time.Now()
 }
 
+// RequeueRoverMissions detaches every unfinished mission from a rover so it can be assigned again
+// The missions go back to the "Queued" state with their priority and no reports, and copies
+// are returned. If keepReports is set, the attempt is archived in PreviousAttempts with its
+// partial reports, so the rover's late reports still have somewhere to go.
+func (mm *MissionManager) RequeueRoverMissions(roverID uint8, keepReports bool) []MissionState {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	var requeued []MissionState
+	for _, mission := range mm.ActiveMissions {
+		if mission.IDRover != roverID || mission.State == "Completed" || mission.State == "Cancelled" {
+			continue
+		}
+		if keepReports {
+			mission.PreviousAttempts = append(mission.PreviousAttempts, MissionAttempt{
+				IDRover:        roverID,
+				Report:         mission.Report,
+				MissingReports: mission.MissingReports,
+			})
+		}
+		mission.IDRover = 0
+		mission.State = "Queued"
+		mission.Report = nil
+		mission.MissingReports = nil
+		mission.LastUpdate = time.Now()
+		requeued = append(requeued, *mission)
+	}
+	return requeued
+}
+
+// AssignMission hands a queued mission over to a rover, moving it to the "Pending" state
+// Returns a copy of the mission, or false if it is no longer queued (e.g. cancelled meanwhile)
+func (mm *MissionManager) AssignMission(id uint16, roverID uint8) (MissionState, bool) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	mission := mm.ActiveMissions[id]
+	if mission == nil || mission.State != "Queued" {
+		return MissionState{}, false
+	}
+	mission.IDRover = roverID
+	mission.State = "Pending"
+	mission.CreatedAt = time.Now()
+	mission.LastUpdate = time.Now()
+	return *mission, true
+}
+
+// CancelMission marks a mission that is still queued or running as "Cancelled"
+// Returns a copy of the mission, whose IDRover tells whether a rover must be told to abort it
+func (mm *MissionManager) CancelMission(id uint16) (MissionState, error) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	mission := mm.ActiveMissions[id]
+	if mission == nil {
+		return MissionState{}, ErrMissionNotFound
+	}
+	if mission.State == "Completed" || mission.State == "Cancelled" {
+		return *mission, ErrMissionFinished
+	}
+	mission.State = "Cancelled"
+	mission.LastUpdate = time.Now()
+	return *mission, nil
+}
+
+// EditMission replaces the task of a mission that is still queued
+// Returns a copy of the edited mission, for the scheduler; AssignMission hands out the edited one anyway
+func (mm *MissionManager) EditMission(id uint16, edited MissionState) (MissionState, error) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	mission := mm.ActiveMissions[id]
+	if mission == nil {
+		return MissionState{}, ErrMissionNotFound
+	}
+	if mission.State != "Queued" {
+		return *mission, ErrMissionNotQueued
+	}
+	mission.TaskType = edited.TaskType
+	mission.Duration = edited.Duration
+	mission.UpdateFrequency = edited.UpdateFrequency
+	mission.Priority = edited.Priority
+	mission.Coordinate = edited.Coordinate
+	mission.LastUpdate = time.Now()
+	return *mission, nil
+}
+
 // DeleteMission removes a mission from the manager
 func (mm *MissionManager) DeleteMission(id uint16) {
 	mm.mu.Lock()
@@ -126,23 +337,27 @@ func (mm *MissionManager) PrintMissions() {
 
 // AssembleImage concatenates all image chunks (by ChunkID order) for the mission into a single byte slice.
 func (m *MissionState) AssembleImage() []byte {
-	// Collect chunks by id
+	// Collect chunks by id (a chunk received twice is kept once)
 	chunks := make(map[uint16][]byte)
-	var ids []int
 	for _, rep := range m.Report {
 		if rep.Header.TaskType == TASK_IMAGE_CAPTURE {
 			var img ImageReportData
-			img.DecodePayload(rep.Payload)
+			if img.DecodePayload(rep.Payload) != nil {
+				continue // Malformed chunk, left out of the image
+			}
 			// copy data to avoid referencing underlying slices
 			dataCopy := make([]byte, len(img.Data))
 			copy(dataCopy, img.Data)
 			chunks[img.ChunkID] = dataCopy
-			ids = append(ids, int(img.ChunkID))
 		}
 	}
-	if len(ids) == 0 {
+	if len(chunks) == 0 {
 		return nil
 	}
+	ids := make([]int, 0, len(chunks))
+	for id := range chunks {
+		ids = append(ids, int(id))
+	}
 	sort.Ints(ids)
 	// Concatenate in order
 	var result []byte
@@ -0,0 +1,139 @@
+package ml
+
+import (
+	"errors"
+	"math"
+	"src/utils"
+	"testing"
+	"time"
+)
+
+func TestMissionValidate(t *testing.T) {
+	valid := MissionState{TaskType: TASK_ENV_ANALYSIS, Priority: 2, Duration: 100, UpdateFrequency: 1,
+		Coordinate: utils.Coordinate{Latitude: -0.78, Longitude: 1}}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("valid mission rejected: %v", err)
+	}
+
+	invalid := map[string]func(*MissionState){
+		"task type":         func(m *MissionState) { m.TaskType = TASK_INSTALLATION + 1 },
+		"priority 0":        func(m *MissionState) { m.Priority = 0 },
+		"priority 4":        func(m *MissionState) { m.Priority = MAX_PRIORITY + 1 },
+		"latitude":          func(m *MissionState) { m.Coordinate.Latitude = 1.01 },
+		"longitude":         func(m *MissionState) { m.Coordinate.Longitude = -1.5 },
+		"NaN":               func(m *MissionState) { m.Coordinate.Latitude = math.NaN() },
+		"negative duration": func(m *MissionState) { m.Duration = -1 },
+		"duration":          func(m *MissionState) { m.Duration = math.MaxUint32 + 1 },
+		"update frequency":  func(m *MissionState) { m.UpdateFrequency = -time.Duration(1) },
+	}
+	for name, corrupt := range invalid {
+		m := valid
+		corrupt(&m)
+		if m.Validate() == nil {
+			t.Errorf("%s: %+v accepted", name, m)
+		}
+	}
+}
+
+func TestNewMissionIDNeverReused(t *testing.T) {
+	mm := NewMissionManager()
+	for want := uint16(1); want <= 3; want++ {
+		id, err := mm.NewMissionID()
+		if err != nil || id != want {
+			t.Fatalf("got ID %d (%v), want %d", id, err, want)
+		}
+		mm.AddMission(&MissionState{ID: id})
+		mm.DeleteMission(id)
+	}
+
+	mm.lastID = math.MaxUint16 - 1
+	if id, err := mm.NewMissionID(); err != nil || id != math.MaxUint16 {
+		t.Fatalf("got ID %d (%v), want %d", id, err, math.MaxUint16)
+	}
+	if _, err := mm.NewMissionID(); !errors.Is(err, ErrMissionIDsExhausted) {
+		t.Fatalf("IDs past %d: got %v", math.MaxUint16, err)
+	}
+}
+
+func TestEditMissionOnlyWhileQueued(t *testing.T) {
+	mm := NewMissionManager()
+	mm.AddMission(&MissionState{ID: 1, TaskType: TASK_IMAGE_CAPTURE, Priority: 3, State: "Queued"})
+	edited := MissionState{ID: 9, TaskType: TASK_TOPO_MAPPING, Priority: 1, State: "Completed"}
+
+	got, err := mm.EditMission(1, edited)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != 1 || got.State != "Queued" || got.TaskType != TASK_TOPO_MAPPING || got.Priority != 1 {
+		t.Errorf("edited mission is %+v", got)
+	}
+	if assigned, ok := mm.AssignMission(1, 4); !ok || assigned.TaskType != TASK_TOPO_MAPPING {
+		t.Errorf("assigned %+v (%v), want the edited mission", assigned, ok)
+	}
+
+	if _, err := mm.EditMission(1, edited); !errors.Is(err, ErrMissionNotQueued) {
+		t.Errorf("editing an assigned mission: got %v", err)
+	}
+	if _, err := mm.EditMission(2, edited); !errors.Is(err, ErrMissionNotFound) {
+		t.Errorf("editing an unknown mission: got %v", err)
+	}
+}
+
+// envReport builds report seq of a mission
+func envReport(missionID, seq uint16, isLast bool) Report {
+	report := NewReport(TASK_ENV_ANALYSIS, missionID, isLast, &EnvReportData{})
+	report.Header.Seq = seq
+	return *report
+}
+
+func TestRequeueRoverMissions(t *testing.T) {
+	for _, keepReports := range []bool{false, true} {
+		mm := NewMissionManager()
+		mm.AddMission(&MissionState{ID: 1, IDRover: 2, Priority: 1, State: "In Progress"})
+		mm.AddMission(&MissionState{ID: 2, IDRover: 2, Priority: 3, State: "Completed"})
+		mm.AddMission(&MissionState{ID: 3, IDRover: 5, Priority: 2, State: "In Progress"})
+		for _, seq := range []uint16{0, 1, 2, 4} {
+			UpdateMission(mm, 2, envReport(1, seq, false))
+		}
+
+		requeued := mm.RequeueRoverMissions(2, keepReports)
+		if len(requeued) != 1 || requeued[0].ID != 1 || requeued[0].Priority != 1 || requeued[0].State != "Queued" || requeued[0].IDRover != 0 {
+			t.Fatalf("keepReports=%v: requeued %+v", keepReports, requeued)
+		}
+		mission := mm.GetMission(1)
+		if len(mission.Report) != 0 || len(mission.MissingReports) != 0 {
+			t.Errorf("keepReports=%v: requeued mission left with reports %v, missing %v", keepReports, mission.Report, mission.MissingReports)
+		}
+		archived := len(mission.PreviousAttempts) == 1 && mission.PreviousAttempts[0].IDRover == 2 &&
+			len(mission.PreviousAttempts[0].Report) == 4 && len(mission.PreviousAttempts[0].MissingReports) == 1
+		if archived != keepReports {
+			t.Errorf("keepReports=%v: previous attempts %+v", keepReports, mission.PreviousAttempts)
+		}
+
+		// The next rover numbers its reports from 0 again, and finishes before the old one's
+		// highest report number: all of them are its own, and the last one completes the mission
+		// Late reports of the old rover only go to its archived attempt
+		mm.AssignMission(1, 7)
+		wantErr := ErrMissionNotAssigned
+		if keepReports {
+			wantErr = ErrMissionReassigned
+		}
+		if _, _, err := UpdateMission(mm, 2, envReport(1, 5, true)); !errors.Is(err, wantErr) {
+			t.Errorf("keepReports=%v: late report of the old rover: got %v", keepReports, err)
+		}
+		if added, missing, _ := UpdateMission(mm, 2, envReport(1, 3, false)); keepReports && (!added || len(missing) != 0) {
+			t.Errorf("keepReports=%v: late report filling the gap: added %v, missing %v", keepReports, added, missing)
+		}
+		if keepReports && len(mission.PreviousAttempts[0].Report) != 6 {
+			t.Errorf("late reports not archived: %+v", mission.PreviousAttempts[0])
+		}
+		for _, seq := range []uint16{0, 1, 2} {
+			if added, _, err := UpdateMission(mm, 7, envReport(1, seq, seq == 2)); !added || err != nil {
+				t.Errorf("keepReports=%v: report %d of the new rover ignored (%v)", keepReports, seq, err)
+			}
+		}
+		if mission.State != "Completed" || len(mission.Report) != 3 || len(mission.MissingReports) != 0 {
+			t.Errorf("keepReports=%v: mission %s with reports %v, missing %v", keepReports, mission.State, mission.Report, mission.MissingReports)
+		}
+	}
+}
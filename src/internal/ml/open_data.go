@@ -0,0 +1,37 @@
+package ml
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// OPEN_DATA_SIZE is the size in bytes of the OpenData payload.
+const OPEN_DATA_SIZE = 8 // 4 (Epoch) + 4 (Session)
+
+// OpenData is the payload of MSG_OPEN.
+// The epoch identifies the rover process: a restarted rover registers again and
+// opens its session with a new epoch, while a re-sent OPEN keeps the old one.
+// Every session the rover opens with the same keys gets a higher number, so a
+// replayed OPEN can't take the place of a newer session.
+type OpenData struct {
+	Epoch   uint32 // Session epoch received in the IDAssignment
+	Session uint32 // Number of the session among those opened with the same keys (from 1)
+}
+
+// Encode serializes the OpenData into bytes (BigEndian).
+func (o *OpenData) Encode() []byte {
+	data := make([]byte, OPEN_DATA_SIZE)
+	binary.BigEndian.PutUint32(data[0:4], o.Epoch)
+	binary.BigEndian.PutUint32(data[4:8], o.Session)
+	return data
+}
+
+// Decode deserializes bytes into OpenData (BigEndian).
+func (o *OpenData) Decode(data []byte) error {
+	if len(data) < OPEN_DATA_SIZE {
+		return fmt.Errorf("open payload too short: %d bytes", len(data))
+	}
+	o.Epoch = binary.BigEndian.Uint32(data[0:4])
+	o.Session = binary.BigEndian.Uint32(data[4:8])
+	return nil
+}
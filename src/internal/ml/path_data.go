@@ -0,0 +1,48 @@
+package ml
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+)
+
+// PATH_TOKEN_SIZE is the size in bytes of a path validation token.
+const PATH_TOKEN_SIZE = 8
+
+// PathData is the payload of MSG_PATH_CHALLENGE and MSG_PATH_RESPONSE.
+// When a rover's packets start arriving from a new address, the mothership sends a
+// random token to that address; the rover echoes it back (signed with the session key)
+// to prove it actually receives traffic there before the mothership migrates to it.
+type PathData struct {
+	Token [PATH_TOKEN_SIZE]byte // Random challenge, echoed unchanged in the response
+}
+
+// NewPathData returns a PathData with a fresh random token.
+func NewPathData() (PathData, error) {
+	var p PathData
+	if _, err := rand.Read(p.Token[:]); err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+// Encode serializes the PathData into bytes.
+func (p *PathData) Encode() []byte {
+	data := make([]byte, PATH_TOKEN_SIZE)
+	copy(data, p.Token[:])
+	return data
+}
+
+// Decode deserializes bytes into PathData.
+func (p *PathData) Decode(data []byte) error {
+	if len(data) < PATH_TOKEN_SIZE {
+		return fmt.Errorf("path payload too short: %d bytes", len(data))
+	}
+	copy(p.Token[:], data[:PATH_TOKEN_SIZE])
+	return nil
+}
+
+// Matches reports whether both tokens are equal (in constant time).
+func (p *PathData) Matches(other PathData) bool {
+	return subtle.ConstantTimeCompare(p.Token[:], other.Token[:]) == 1
+}
@@ -0,0 +1,32 @@
+package ml
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// PING_DATA_SIZE is the size in bytes of the PingData payload.
+const PING_DATA_SIZE = 4
+
+// PingData is the payload of MSG_PING and MSG_PONG.
+// The mothership probes each rover's MissionLink path periodically; the rover echoes the
+// probe number back so late PONGs of earlier PINGs aren't mistaken for the current one.
+type PingData struct {
+	Seq uint32 // Probe number, echoed unchanged in the PONG
+}
+
+// Encode serializes the PingData into bytes.
+func (p *PingData) Encode() []byte {
+	data := make([]byte, PING_DATA_SIZE)
+	binary.BigEndian.PutUint32(data, p.Seq)
+	return data
+}
+
+// Decode deserializes bytes into PingData.
+func (p *PingData) Decode(data []byte) error {
+	if len(data) < PING_DATA_SIZE {
+		return fmt.Errorf("ping payload too short: %d bytes", len(data))
+	}
+	p.Seq = binary.BigEndian.Uint32(data)
+	return nil
+}
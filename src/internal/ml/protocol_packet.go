@@ -1,7 +1,11 @@
 package ml
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
 	"encoding/binary"
+	"fmt"
+	"hash/crc32"
 )
 
 // Message types.
@@ -11,6 +15,31 @@ const (
 	MSG_ACK
 	MSG_REPORT
 	MSG_REQUEST
+	MSG_HELLO
+	MSG_HELLO_ACK
+	MSG_ERROR
+	MSG_OPEN
+	MSG_OPEN_ACK
+	MSG_CLOSE
+	MSG_PATH_CHALLENGE
+	MSG_PATH_RESPONSE
+	MSG_PING
+	MSG_PONG
+	MSG_FEC
+	MSG_CANCEL
+)
+
+// Protocol versions.
+const (
+	PROTOCOL_VERSION     = 1 // Version spoken by this build
+	MIN_PROTOCOL_VERSION = 1 // Oldest version this build still accepts
+)
+
+// Header flags (4 bits).
+const (
+	FLAG_ENCRYPTED  uint8 = 1 << iota // Payload is sealed with the session AEAD
+	FLAG_FRAGMENT                     // Payload is one fragment of a larger message (see FragmentHeader)
+	FLAG_COMPRESSED                   // Message payload is compressed with the negotiated Codec
 )
 
 // PacketType represents the type of message
@@ -18,11 +47,14 @@ type PacketType uint8
 
 // Packet is the base structure of the packet.
 type Packet struct {
+	Version  uint8 // Protocol version (4 higher bits of the first byte)
+	Flags    uint8 // Header flags (4 lower bits of the first byte)
 	RoverId  uint8
 	MsgType  PacketType
 	SeqNum   uint32
 	AckNum   uint32
-	Checksum uint8
+	Checksum uint32
+	MAC      [MACSize]byte
 	Payload  []byte
 }
 
@@ -39,13 +71,52 @@ func (pt PacketType) String() string {
 		return "MSG_REPORT"
 	case MSG_REQUEST:
 		return "MSG_REQUEST"
+	case MSG_HELLO:
+		return "MSG_HELLO"
+	case MSG_HELLO_ACK:
+		return "MSG_HELLO_ACK"
+	case MSG_ERROR:
+		return "MSG_ERROR"
+	case MSG_OPEN:
+		return "MSG_OPEN"
+	case MSG_OPEN_ACK:
+		return "MSG_OPEN_ACK"
+	case MSG_CLOSE:
+		return "MSG_CLOSE"
+	case MSG_PATH_CHALLENGE:
+		return "MSG_PATH_CHALLENGE"
+	case MSG_PATH_RESPONSE:
+		return "MSG_PATH_RESPONSE"
+	case MSG_PING:
+		return "MSG_PING"
+	case MSG_PONG:
+		return "MSG_PONG"
+	case MSG_FEC:
+		return "MSG_FEC"
+	case MSG_CANCEL:
+		return "MSG_CANCEL"
 	default:
 		return "UNKNOWN"
 	}
 }
 
+// MACSize is the size in bytes of the truncated HMAC-SHA256 tag carried by each packet.
+const MACSize = 16
+
+// IsUnsequenced reports whether packets of this type are sent once, without consuming
+// a SeqNum nor being retransmitted (ACKs, errors, path validation, keepalive probes and
+// FEC parity).
+func (pt PacketType) IsUnsequenced() bool {
+	switch pt {
+	case MSG_ACK, MSG_ERROR, MSG_PATH_CHALLENGE, MSG_PATH_RESPONSE, MSG_PING, MSG_PONG, MSG_FEC:
+		return true
+	default:
+		return false
+	}
+}
+
 // PacketHeaderSize is the size of the packet header in bytes.
-const PacketHeaderSize = 11 // 1 (RoverId) + 1 (MsgType) + 4 (SeqNum) + 4 (AckNum) + 1 (Checksum) - Payload é variável
+const PacketHeaderSize = 31 // 1 (Version + Flags) + 1 (RoverId) + 1 (MsgType) + 4 (SeqNum) + 4 (AckNum) + 4 (Checksum) + 16 (MAC) - Payload é variável
 
 // Enconde serializes the packet into bytes.
 func (p *Packet) Encode() []byte {
@@ -53,35 +124,90 @@ func (p *Packet) Encode() []byte {
 	totalSize := PacketHeaderSize + len(p.Payload)
 	data := make([]byte, totalSize)
 
-	data[0] = p.RoverId
-	data[1] = uint8(p.MsgType)
-	binary.BigEndian.PutUint32(data[2:6], p.SeqNum)
-	binary.BigEndian.PutUint32(data[6:10], p.AckNum)
-	data[10] = p.Checksum
+	// versionAndFlags combines Version (4 higher bits) and Flags (4 lower bits)
+	data[0] = (p.Version << 4) | (p.Flags & 0x0F)
+	data[1] = p.RoverId
+	data[2] = uint8(p.MsgType)
+	binary.BigEndian.PutUint32(data[3:7], p.SeqNum)
+	binary.BigEndian.PutUint32(data[7:11], p.AckNum)
+	binary.BigEndian.PutUint32(data[11:15], p.Checksum)
+	copy(data[15:15+MACSize], p.MAC[:])
 	copy(data[PacketHeaderSize:], p.Payload)
 
 	return data
 }
 
 // Decode deserializes bytes into a Packet (BigEndian).
-func (p *Packet) Decode(data []byte) {
-	p.RoverId = data[0]
-	p.MsgType = PacketType(data[1])
-	p.SeqNum = binary.BigEndian.Uint32(data[2:6])
-	p.AckNum = binary.BigEndian.Uint32(data[6:10])
-	p.Checksum = data[10]
+// Datagrams shorter than the header are rejected; the checksum is verified by the caller.
+func (p *Packet) Decode(data []byte) error {
+	if len(data) < PacketHeaderSize {
+		return fmt.Errorf("packet too short: %d bytes", len(data))
+	}
+	p.Version = (data[0] >> 4) & 0x0F
+	p.Flags = data[0] & 0x0F
+	p.RoverId = data[1]
+	p.MsgType = PacketType(data[2])
+	p.SeqNum = binary.BigEndian.Uint32(data[3:7])
+	p.AckNum = binary.BigEndian.Uint32(data[7:11])
+	p.Checksum = binary.BigEndian.Uint32(data[11:15])
+	copy(p.MAC[:], data[15:15+MACSize])
 
+	p.Payload = nil
 	if len(data) > PacketHeaderSize {
 		p.Payload = make([]byte, len(data)-PacketHeaderSize)
 		copy(p.Payload, data[PacketHeaderSize:])
 	}
+	return nil
+}
+
+// IsVersionSupported reports whether a peer speaking the given version can be served.
+func IsVersionSupported(version uint8) bool {
+	return version >= MIN_PROTOCOL_VERSION && version <= PROTOCOL_VERSION
+}
+
+// crc32cTable is the Castagnoli polynomial table used for packet checksums.
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Checksum computes the CRC-32C of data.
+func Checksum(data []byte) uint32 {
+	return crc32.Checksum(data, crc32cTable)
+}
+
+// ComputeChecksum returns the CRC-32C over the whole encoded packet (header and payload),
+// with the Checksum field itself zeroed.
+func (p *Packet) ComputeChecksum() uint32 {
+	unsummed := *p
+	unsummed.Checksum = 0
+	return Checksum(unsummed.Encode())
+}
+
+// ComputeMAC returns the truncated HMAC-SHA256 of the header and payload under key.
+// The MAC and Checksum fields are zeroed while the tag is computed, since the
+// checksum is filled in after signing.
+func (p *Packet) ComputeMAC(key []byte) [MACSize]byte {
+	unsigned := *p
+	unsigned.MAC = [MACSize]byte{}
+	unsigned.Checksum = 0
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(unsigned.Encode())
+
+	var tag [MACSize]byte
+	copy(tag[:], mac.Sum(nil))
+	return tag
+}
+
+// Sign fills the MAC field using the given key.
+func (p *Packet) Sign(key []byte) {
+	p.MAC = p.ComputeMAC(key)
 }
 
-// Simple checksum calculation.
-func Checksum(data []byte) uint8 {
-	var sum uint32
-	for _, b := range data {
-		sum += uint32(b)
+// VerifyMAC reports whether the packet carries a valid MAC for key.
+// Packets are never considered authentic under an empty key.
+func (p *Packet) VerifyMAC(key []byte) bool {
+	if len(key) == 0 {
+		return false
 	}
-	return uint8(sum % 256)
+	expected := p.ComputeMAC(key)
+	return hmac.Equal(expected[:], p.MAC[:])
 }
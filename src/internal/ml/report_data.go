@@ -15,14 +15,22 @@ const (
     TASK_TOPO_MAPPING
     TASK_INSTALLATION
 
-    REPORT_HEADER_SIZE = 4 // 1 (TaskType) + 2 (MissionID) + 1 (IsLastReport)
+    REPORT_HEADER_SIZE = 6 // 1 (TaskType) + 2 (MissionID) + 2 (Seq) + 1 (Flags)
+)
+
+// Report flags (last byte of the header).
+const (
+    REPORT_FLAG_LAST    = 1 << iota // Last report of the mission
+    REPORT_FLAG_ABORTED             // Mission cancelled by the mothership, the report carries no task data
 )
 
 // Generic Header for all reports.
 type ReportHeader struct {
     TaskType     uint8      // Task type of the report
     MissionID    uint16     // Mission ID associated with the report
+    Seq          uint16     // Number of the report within its mission, identifies replays
     IsLastReport bool       // Indicates if this is the last report in the sequence
+    Aborted      bool       // Indicates that the mission was interrupted (always the last report)
 }
 
 // EncodeHeader serializes the ReportHeader into bytes.
@@ -30,15 +38,27 @@ func (h *ReportHeader) EncodeHeader() []byte {
     data := make([]byte, REPORT_HEADER_SIZE)
     data[0] = h.TaskType
     binary.BigEndian.PutUint16(data[1:3], h.MissionID)
-    data[3] = boolToByte(h.IsLastReport)
+    binary.BigEndian.PutUint16(data[3:5], h.Seq)
+    if h.IsLastReport {
+        data[5] |= REPORT_FLAG_LAST
+    }
+    if h.Aborted {
+        data[5] |= REPORT_FLAG_ABORTED
+    }
     return data
 }
 
 // DecodeHeader deserializes bytes into ReportHeader.
-func (h *ReportHeader) DecodeHeader(b []byte) {
+func (h *ReportHeader) DecodeHeader(b []byte) error {
+    if len(b) < REPORT_HEADER_SIZE {
+        return fmt.Errorf("report header too short: %d bytes", len(b))
+    }
     h.TaskType = b[0]
     h.MissionID = binary.BigEndian.Uint16(b[1:3])
-    h.IsLastReport = b[3] == 1
+    h.Seq = binary.BigEndian.Uint16(b[3:5])
+    h.IsLastReport = b[5]&REPORT_FLAG_LAST != 0
+    h.Aborted = b[5]&REPORT_FLAG_ABORTED != 0
+    return nil
 }
 
 // Report with generic payload.
@@ -57,8 +77,9 @@ func (r *Report) Encode() []byte {
 
 // Decode deserializes bytes into Report.
 func (r *Report) Decode(b []byte) error {
-
-    r.Header.DecodeHeader(b[:REPORT_HEADER_SIZE])
+    if err := r.Header.DecodeHeader(b); err != nil {
+        return err
+    }
     r.Payload = make([]byte, len(b)-REPORT_HEADER_SIZE)
     copy(r.Payload, b[REPORT_HEADER_SIZE:])
     return nil
@@ -87,28 +108,28 @@ func (r *Report) DecodeTyped() (any, error) {
     switch r.Header.TaskType {
     case TASK_IMAGE_CAPTURE:
         var img ImageReportData
-        img.DecodePayload(r.Payload)
-        return img, nil
+        err := img.DecodePayload(r.Payload)
+        return img, err
     case TASK_SAMPLE_COLLECTION:
         var sample SampleReportData
-        sample.DecodePayload(r.Payload)
-        return sample, nil
+        err := sample.DecodePayload(r.Payload)
+        return sample, err
     case TASK_ENV_ANALYSIS:
         var env EnvReportData
-        env.DecodePayload(r.Payload)
-        return env, nil
+        err := env.DecodePayload(r.Payload)
+        return env, err
     case TASK_REPAIR_RESCUE:
         var rep RepairReportData
-        rep.DecodePayload(r.Payload)
-        return rep, nil
+        err := rep.DecodePayload(r.Payload)
+        return rep, err
     case TASK_TOPO_MAPPING:
         var topo TopoReportData
-        topo.DecodePayload(r.Payload)
-        return topo, nil
+        err := topo.DecodePayload(r.Payload)
+        return topo, err
     case TASK_INSTALLATION:
         var inst InstallReportData
-        inst.DecodePayload(r.Payload)
-        return inst, nil
+        err := inst.DecodePayload(r.Payload)
+        return inst, err
     default:
         return nil, fmt.Errorf("unknown TaskType: %d", r.Header.TaskType)
     }
@@ -117,10 +138,12 @@ func (r *Report) DecodeTyped() (any, error) {
 // String returns a human-readable representation of the Report.
 func (r *Report) String() string {
     return fmt.Sprintf(
-        "Report: Type=%d MissionID=%d IsLast=%v PayloadSize=%d",
+        "Report: Type=%d MissionID=%d Seq=%d IsLast=%v Aborted=%v PayloadSize=%d",
         r.Header.TaskType,
         r.Header.MissionID,
+        r.Header.Seq,
         r.Header.IsLastReport,
+        r.Header.Aborted,
         len(r.Payload),
     )
 }
@@ -141,6 +164,7 @@ type ImageReportData struct {
     Data    []byte      // Image data bytes
 }
 
+// EncodePayload serializes the ImageReportData into bytes.
 func (img *ImageReportData) EncodePayload() []byte {
     payload := make([]byte, 2+len(img.Data))
     binary.BigEndian.PutUint16(payload[0:2], img.ChunkID)
@@ -148,10 +172,15 @@ func (img *ImageReportData) EncodePayload() []byte {
     return payload
 }
 
-func (img *ImageReportData) DecodePayload(payload []byte) {
+// DecodePayload deserializes bytes into ImageReportData.
+func (img *ImageReportData) DecodePayload(payload []byte) error {
+    if len(payload) < 2 {
+        return fmt.Errorf("image report too short: %d bytes", len(payload))
+    }
     img.ChunkID = binary.BigEndian.Uint16(payload[0:2])
     img.Data = make([]byte, len(payload)-2)
     copy(img.Data, payload[2:])
+    return nil
 }
 
 // ====== SAMPLE COLLECTION DATA ======
@@ -188,18 +217,30 @@ func (s *SampleReportData) EncodePayload() []byte {
 }
 
 // DecodePayload deserializes bytes into SampleReportData.
-func (s *SampleReportData) DecodePayload(payload []byte) {
+func (s *SampleReportData) DecodePayload(payload []byte) error {
+    s.Components = nil
+    if len(payload) < 1 {
+        return fmt.Errorf("sample report is empty")
+    }
     count := int(payload[0])
-    s.Components = make([]Component, count)
+    components := make([]Component, count)
     idx := 1
     for i := 0; i < count; i++ {
+        if idx >= len(payload) {
+            return fmt.Errorf("sample report truncated at component %d of %d", i, count)
+        }
         nameLen := int(payload[idx])
         idx++
-        s.Components[i].Name = string(payload[idx : idx+nameLen])
+        if idx+nameLen+4 > len(payload) {
+            return fmt.Errorf("sample report truncated at component %d of %d", i, count)
+        }
+        components[i].Name = string(payload[idx : idx+nameLen])
         idx += nameLen
-        s.Components[i].Percentage = math.Float32frombits(binary.BigEndian.Uint32(payload[idx : idx+4]))
+        components[i].Percentage = math.Float32frombits(binary.BigEndian.Uint32(payload[idx : idx+4]))
         idx += 4
     }
+    s.Components = components
+    return nil
 }
 
 // ====== ENVIRONMENTAL ANALYSIS DATA ======
@@ -226,13 +267,17 @@ func (e *EnvReportData) EncodePayload() []byte {
 }
 
 // DecodePayload deserializes bytes into EnvReportData.
-func (e *EnvReportData) DecodePayload(payload []byte) {
+func (e *EnvReportData) DecodePayload(payload []byte) error {
+    if len(payload) < 4*6 {
+        return fmt.Errorf("environment report too short: %d bytes", len(payload))
+    }
     e.Temp = math.Float32frombits(binary.BigEndian.Uint32(payload[0:4]))
     e.Oxygen = math.Float32frombits(binary.BigEndian.Uint32(payload[4:8]))
     e.Pressure = math.Float32frombits(binary.BigEndian.Uint32(payload[8:12]))
     e.Humidity = math.Float32frombits(binary.BigEndian.Uint32(payload[12:16]))
     e.WindSpeed = math.Float32frombits(binary.BigEndian.Uint32(payload[16:20]))
     e.Radiation = math.Float32frombits(binary.BigEndian.Uint32(payload[20:24]))
+    return nil
 }
 
 // ====== REPAIR/RESCUE DATA ======
@@ -251,9 +296,13 @@ func (r *RepairReportData) EncodePayload() []byte {
 }
 
 // DecodePayload deserializes bytes into RepairReportData.
-func (r *RepairReportData) DecodePayload(payload []byte) {
+func (r *RepairReportData) DecodePayload(payload []byte) error {
+    if len(payload) < 2 {
+        return fmt.Errorf("repair report too short: %d bytes", len(payload))
+    }
     r.ProblemID = payload[0]
     r.Repairable = payload[1] == 1
+    return nil
 }
 
 // ====== TOPOGRAPHIC MAPPING DATA ======
@@ -274,10 +323,14 @@ func (t *TopoReportData) EncodePayload() []byte {
 }
 
 // DecodePayload deserializes bytes into TopoReportData.
-func (t *TopoReportData) DecodePayload(payload []byte) {
+func (t *TopoReportData) DecodePayload(payload []byte) error {
+    if len(payload) < 20 {
+        return fmt.Errorf("topography report too short: %d bytes", len(payload))
+    }
     t.Latitude = math.Float64frombits(binary.BigEndian.Uint64(payload[0:8]))
     t.Longitude = math.Float64frombits(binary.BigEndian.Uint64(payload[8:16]))
     t.Height = math.Float32frombits(binary.BigEndian.Uint32(payload[16:20]))
+    return nil
 }
 
 // ====== INSTRUMENT INSTALLATION DATA ======
@@ -294,8 +347,12 @@ func (i *InstallReportData) EncodePayload() []byte {
 }
 
 // DecodePayload deserializes bytes into InstallReportData.
-func (i *InstallReportData) DecodePayload(payload []byte) {
+func (i *InstallReportData) DecodePayload(payload []byte) error {
+    if len(payload) < 1 {
+        return fmt.Errorf("installation report is empty")
+    }
     i.Success = payload[0] == 1
+    return nil
 }
 
 // Helper to convert bool to byte.
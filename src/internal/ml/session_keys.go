@@ -0,0 +1,278 @@
+package ml
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// IDAssignmentSize is the size in bytes of the ID handshake reply sent by the mothership.
+const IDAssignmentSize = 55 // 1 (ID) + 1 (UpdateFrequency) + 32 (PublicKey) + 4 (Epoch) + 1 (Encrypted) + 16 (ReclaimToken)
+
+// HandshakeKeySize is the size in bytes of an X25519 public key exchanged during registration.
+const HandshakeKeySize = 32
+
+// ReclaimTokenSize is the size in bytes of the token that proves a rover held an ID.
+const ReclaimTokenSize = 16
+
+// IDRequestSize is the size in bytes of the ID handshake request sent by the rover.
+const IDRequestSize = 49 // 32 (PublicKey) + 1 (RequestedID) + 16 (ReclaimToken)
+
+// IDRequest opens the TCP ID handshake.
+type IDRequest struct {
+	PublicKey    [HandshakeKeySize]byte // Rover X25519 public key
+	RequestedID  uint8                  // ID to reclaim after a restart (0 = assign a new one)
+	ReclaimToken [ReclaimTokenSize]byte // Token of the last assignment of RequestedID
+}
+
+// Encode serializes the IDRequest into bytes.
+func (r *IDRequest) Encode() []byte {
+	data := make([]byte, IDRequestSize)
+	copy(data[0:32], r.PublicKey[:])
+	data[32] = r.RequestedID
+	copy(data[33:49], r.ReclaimToken[:])
+	return data
+}
+
+// Decode deserializes bytes into an IDRequest.
+func (r *IDRequest) Decode(data []byte) error {
+	if len(data) < IDRequestSize {
+		return fmt.Errorf("ID request too short: %d bytes", len(data))
+	}
+	copy(r.PublicKey[:], data[0:32])
+	r.RequestedID = data[32]
+	copy(r.ReclaimToken[:], data[33:49])
+	return nil
+}
+
+// IDAssignment is the reply of the TCP ID handshake.
+// The rover first sends an IDRequest; the mothership answers with this structure.
+// ID 0 means the requested ID was refused.
+type IDAssignment struct {
+	ID              uint8                  // Assigned rover ID
+	UpdateFrequency uint8                  // Telemetry update frequency in seconds
+	PublicKey       [HandshakeKeySize]byte // Mothership X25519 public key
+	Epoch           uint32                 // Session epoch used to derive AEAD nonces
+	Encrypted       bool                   // Whether payloads must be encrypted
+	ReclaimToken    [ReclaimTokenSize]byte // Proof of this assignment, needed to reclaim the ID later
+}
+
+// Encode serializes the IDAssignment into bytes (BigEndian).
+func (a *IDAssignment) Encode() []byte {
+	data := make([]byte, IDAssignmentSize)
+	data[0] = a.ID
+	data[1] = a.UpdateFrequency
+	copy(data[2:34], a.PublicKey[:])
+	binary.BigEndian.PutUint32(data[34:38], a.Epoch)
+	data[38] = boolToByte(a.Encrypted)
+	copy(data[39:55], a.ReclaimToken[:])
+	return data
+}
+
+// Decode deserializes bytes into an IDAssignment (BigEndian).
+func (a *IDAssignment) Decode(data []byte) error {
+	if len(data) < IDAssignmentSize {
+		return fmt.Errorf("ID assignment too short: %d bytes", len(data))
+	}
+	a.ID = data[0]
+	a.UpdateFrequency = data[1]
+	copy(a.PublicKey[:], data[2:34])
+	a.Epoch = binary.BigEndian.Uint32(data[34:38])
+	a.Encrypted = data[38] == 1
+	copy(a.ReclaimToken[:], data[39:55])
+	return nil
+}
+
+// MaxSessions is how many sessions can be opened with the keys of one registration:
+// the session number takes 3 bytes of the AEAD nonce. The rover then registers again.
+const MaxSessions = 1<<24 - 1
+
+// SessionKeys holds the per-rover secrets negotiated during registration.
+type SessionKeys struct {
+	AuthKey []byte         // HMAC key used to sign packets
+	Cipher  *PayloadCipher // AEAD for payloads (nil when encryption is disabled)
+	Epoch   uint32         // Session epoch chosen by the mothership at registration
+	Session uint32         // Number of the last session opened with these keys (see OpenData)
+}
+
+// ForSession returns the keys of the given session: the AEAD nonces it uses can't
+// collide with those of any other session opened with the same keys.
+func (k *SessionKeys) ForSession(session uint32) (*SessionKeys, error) {
+	if session == 0 || session > MaxSessions {
+		return nil, fmt.Errorf("invalid session number %d", session)
+	}
+	keys := *k
+	keys.Session = session
+	if k.Cipher != nil {
+		cipher := *k.Cipher
+		cipher.session = session
+		keys.Cipher = &cipher
+	}
+	return &keys, nil
+}
+
+// NewHandshakeKey generates an ephemeral X25519 key pair for the ID handshake.
+func NewHandshakeKey() (*ecdh.PrivateKey, error) {
+	return ecdh.X25519().GenerateKey(rand.Reader)
+}
+
+// DeriveSessionKeys runs X25519 against the peer public key and derives the HMAC key
+// and, if encrypted is set, the AEAD used for payloads in the given epoch.
+func DeriveSessionKeys(priv *ecdh.PrivateKey, peerPublic []byte, epoch uint32, encrypted bool) (*SessionKeys, error) {
+	pub, err := ecdh.X25519().NewPublicKey(peerPublic)
+	if err != nil {
+		return nil, fmt.Errorf("invalid peer public key: %v", err)
+	}
+	shared, err := priv.ECDH(pub)
+	if err != nil {
+		return nil, fmt.Errorf("key agreement failed: %v", err)
+	}
+
+	keys := &SessionKeys{AuthKey: deriveKey(shared, "missionlink-auth"), Epoch: epoch}
+	if encrypted {
+		keys.Cipher, err = NewPayloadCipher(deriveKey(shared, "missionlink-enc"), epoch)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// deriveKey derives a 32-byte subkey from the shared secret for the given label.
+func deriveKey(secret []byte, label string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(label))
+	return mac.Sum(nil)
+}
+
+// NewEpoch returns a random session epoch.
+func NewEpoch() (uint32, error) {
+	return randomUint32()
+}
+
+// NewReclaimToken returns a random token proving an ID assignment.
+func NewReclaimToken() ([ReclaimTokenSize]byte, error) {
+	var token [ReclaimTokenSize]byte
+	_, err := rand.Read(token[:])
+	return token, err
+}
+
+// NewISN returns a random initial sequence number for a MissionLink session (MSG_OPEN / MSG_OPEN_ACK).
+// Sessions opened with the same keys may reuse SeqNums; the session number in the nonce keeps them apart.
+func NewISN() (uint32, error) {
+	return randomUint32()
+}
+
+// randomUint32 reads a uint32 from the system CSPRNG.
+func randomUint32() (uint32, error) {
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(b[:]), nil
+}
+
+// ====== PAYLOAD ENCRYPTION ======
+
+// PayloadCipher encrypts packet payloads with AES-256-GCM.
+// Nonces are derived from the session epoch, the direction, the session number and the
+// packet SeqNum, so a retransmission of the same packet produces the same ciphertext.
+type PayloadCipher struct {
+	aead    cipher.AEAD
+	epoch   uint32
+	session uint32 // Set by SessionKeys.ForSession
+}
+
+// NewPayloadCipher creates a PayloadCipher for a 32-byte key and session epoch.
+func NewPayloadCipher(key []byte, epoch uint32) (*PayloadCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &PayloadCipher{aead: aead, epoch: epoch}, nil
+}
+
+// Applies reports whether the packet payload is encrypted on the wire.
+// Unsequenced packets (ACKs, errors) carry no application data and would reuse
+// SeqNum 0 as nonce; empty payloads have nothing to protect. MSG_OPEN stays in
+// cleartext so the mothership can read its epoch before any session exists.
+func (c *PayloadCipher) Applies(p *Packet) bool {
+	return c != nil && !p.MsgType.IsUnsequenced() && p.MsgType != MSG_OPEN && len(p.Payload) > 0
+}
+
+// Overhead returns how many bytes Seal adds to a payload (0 without encryption).
+func (c *PayloadCipher) Overhead() int {
+	if c == nil {
+		return 0
+	}
+	return c.aead.Overhead()
+}
+
+// nonce builds the 12-byte GCM nonce: Epoch (4) + Direction (1) + Session (3) + SeqNum (4).
+// Packets sent by the mothership carry RoverId 0, which separates both directions.
+func (c *PayloadCipher) nonce(p *Packet) []byte {
+	nonce := make([]byte, c.aead.NonceSize())
+	binary.BigEndian.PutUint32(nonce[0:4], c.epoch)
+	if p.RoverId != 0 {
+		nonce[4] = 1
+	}
+	nonce[5] = byte(c.session >> 16)
+	nonce[6] = byte(c.session >> 8)
+	nonce[7] = byte(c.session)
+	binary.BigEndian.PutUint32(nonce[8:12], p.SeqNum)
+	return nonce
+}
+
+// additionalData returns the header fields bound to the ciphertext.
+func additionalData(p *Packet) []byte {
+	ad := make([]byte, 11)
+	ad[0] = (p.Version << 4) | (p.Flags & 0x0F)
+	ad[1] = p.RoverId
+	ad[2] = uint8(p.MsgType)
+	binary.BigEndian.PutUint32(ad[3:7], p.SeqNum)
+	binary.BigEndian.PutUint32(ad[7:11], p.AckNum)
+	return ad
+}
+
+// Seal encrypts the packet payload in place and sets FLAG_ENCRYPTED.
+func (c *PayloadCipher) Seal(p *Packet) {
+	if !c.Applies(p) {
+		return
+	}
+	p.Flags |= FLAG_ENCRYPTED
+	p.Payload = c.aead.Seal(nil, c.nonce(p), p.Payload, additionalData(p))
+}
+
+// Open decrypts the packet payload in place.
+// Cleartext payloads are refused when the session is encrypted, and vice versa.
+func (c *PayloadCipher) Open(p *Packet) error {
+	encrypted := p.Flags&FLAG_ENCRYPTED != 0
+	if !c.Applies(p) {
+		if encrypted {
+			return errors.New("unexpected encrypted payload")
+		}
+		return nil
+	}
+	if !encrypted {
+		return errors.New("cleartext payload on encrypted session")
+	}
+	if len(p.Payload) < c.aead.Overhead() {
+		return errors.New("ciphertext too short")
+	}
+	plain, err := c.aead.Open(nil, c.nonce(p), p.Payload, additionalData(p))
+	if err != nil {
+		return err
+	}
+	p.Payload = plain
+	return nil
+}
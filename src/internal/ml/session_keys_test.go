@@ -0,0 +1,70 @@
+package ml
+
+import (
+	"bytes"
+	"testing"
+)
+
+// encryptedKeys negotiates encrypted session keys between two fresh handshake keys
+func encryptedKeys(t *testing.T) *SessionKeys {
+	t.Helper()
+	rover, err := NewHandshakeKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mothership, err := NewHandshakeKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := DeriveSessionKeys(rover, mothership.PublicKey().Bytes(), 77, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func TestSessionsDoNotShareNonces(t *testing.T) {
+	keys := encryptedKeys(t)
+	first, err := keys.ForSession(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := keys.ForSession(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A re-opened session may pick SeqNums the previous one already used
+	packet := func() Packet {
+		return Packet{RoverId: 3, MsgType: MSG_REPORT, SeqNum: 1000, Payload: []byte("same report, same SeqNum")}
+	}
+	sealedFirst, sealedSecond := packet(), packet()
+	first.Cipher.Seal(&sealedFirst)
+	second.Cipher.Seal(&sealedSecond)
+	if bytes.Equal(sealedFirst.Payload, sealedSecond.Payload) {
+		t.Fatal("two sessions encrypted the same SeqNum with the same nonce")
+	}
+
+	if err := second.Cipher.Open(&sealedFirst); err == nil {
+		t.Error("a packet of session 1 was accepted by session 2")
+	}
+	if err := second.Cipher.Open(&sealedSecond); err != nil || string(sealedSecond.Payload) != "same report, same SeqNum" {
+		t.Errorf("session 2 couldn't open its own packet: %v", err)
+	}
+}
+
+func TestForSessionBounds(t *testing.T) {
+	keys := encryptedKeys(t)
+	for _, session := range []uint32{0, MaxSessions + 1} {
+		if _, err := keys.ForSession(session); err == nil {
+			t.Errorf("session %d accepted", session)
+		}
+	}
+	last, err := keys.ForSession(MaxSessions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys.Cipher.session != 0 || last.Cipher.session != MaxSessions {
+		t.Error("ForSession changed the registration keys")
+	}
+}
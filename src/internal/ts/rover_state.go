@@ -15,8 +15,15 @@ type RoverTSState struct {
 	UpdateFrequency uint             `json:"updateFrequency"` // Update frequency in seconds
 	MissedTelemetry int              `json:"missedTelemetry"` // Consecutive telemetry failures count
 	QueuedMissions  QueueInfo        `json:"queuedMissions"`  // Mission queue status
+	MissionLink     string           `json:"missionLink"`     // MissionLink (UDP) health: ML_HEALTHY or ML_DEGRADED
 }
 
+// MissionLink health values, tracked by MSG_PING / MSG_PONG keepalives
+const (
+	ML_HEALTHY  = "Healthy"
+	ML_DEGRADED = "MissionLink degraded"
+)
+
 // QueueInfo holds information about the mission queue
 type QueueInfo struct {
 	Priority1Count uint8    `json:"priority1Count"` // Number of priority 1 missions
@@ -75,6 +82,15 @@ func (rm *RoverManager) UpdateRover(id uint8, state string, battery uint8, speed
 	}
 }
 
+// SetMissionLink updates the MissionLink health of an existing rover.
+func (rm *RoverManager) SetMissionLink(id uint8, health string) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	if rover, ok := rm.rovers[id]; ok {
+		rover.MissionLink = health
+	}
+}
+
 // RemoveRover removes a rover from the manager by its ID.
 func (rm *RoverManager) RemoveRover(id uint8) {
 	rm.mu.Lock()
@@ -89,6 +105,17 @@ func (rm *RoverManager) GetRover(id uint8) *RoverTSState {
 	return rm.rovers[id]
 }
 
+// Snapshot returns a copy of a rover's telemetry state, safe to read while telemetry arrives.
+func (rm *RoverManager) Snapshot(id uint8) (RoverTSState, bool) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rover, ok := rm.rovers[id]
+	if !ok {
+		return RoverTSState{}, false
+	}
+	return *rover, true
+}
+
 // ListRovers returns a list of all registered rovers.
 func (rm *RoverManager) ListRovers() []*RoverTSState {
 	rm.mu.Lock()
@@ -2,6 +2,7 @@ package ts
 
 import (
 	"encoding/binary"
+	"fmt"
 	"math"
 	"src/utils"
 )
@@ -31,6 +32,9 @@ type TelemetryPacket struct {
 // TelemetryPacketSize is the size in bytes of the serialized TelemetryPacket.
 const TelemetryPacketSize = 36 // 1 (RoverID) + 8 (Timestamp) + 8 (Latitude) + 8 (Longitude) + 1 (State + WheelStatus) + 1 (Battery) + 4 (Speed) + 2 (Temperature) + 3 (Queue counts)
 
+// TelemetryPacketMinSize is the size of a TelemetryPacket sent without the queue counts.
+const TelemetryPacketMinSize = 33
+
 // Encode serializes the TelemetryPacket data to bytes (BigEndian).
 func (t *TelemetryPacket) Encode() []byte {
 	data := make([]byte, TelemetryPacketSize)
@@ -52,6 +56,9 @@ func (t *TelemetryPacket) Encode() []byte {
 
 // Decode deserializes bytes into TelemetryPacket data (BigEndian).
 func (t *TelemetryPacket) Decode(data []byte) error {
+	if len(data) < TelemetryPacketMinSize {
+		return fmt.Errorf("telemetry packet too short: %d bytes", len(data))
+	}
 	t.RoverID = data[0]
 	t.Timestamp = int64(binary.BigEndian.Uint64(data[1:]))
 	t.Position.Latitude = math.Float64frombits(binary.BigEndian.Uint64(data[9:]))
@@ -62,7 +69,8 @@ func (t *TelemetryPacket) Decode(data []byte) error {
 	t.Battery = data[26]
 	t.Speed = math.Float32frombits(binary.BigEndian.Uint32(data[27:]))
 	t.Temperature = int16(binary.BigEndian.Uint16(data[31:]))
-	if len(data) >= 36 {
+	t.QueueP1Count, t.QueueP2Count, t.QueueP3Count = 0, 0, 0
+	if len(data) >= TelemetryPacketSize {
 		t.QueueP1Count = data[33]
 		t.QueueP2Count = data[34]
 		t.QueueP3Count = data[35]
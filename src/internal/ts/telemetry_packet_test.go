@@ -0,0 +1,56 @@
+package ts
+
+import (
+	"bytes"
+	"testing"
+	"testing/quick"
+)
+
+func TestTelemetryPacketRoundTrip(t *testing.T) {
+	check := func(p TelemetryPacket) bool {
+		p.State &= 0x0F
+		p.WheelStatus &= 0x0F
+		var got TelemetryPacket
+		return got.Decode(p.Encode()) == nil && got == p
+	}
+	if err := quick.Check(check, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// Packets without the queue counts are still accepted; shorter ones are not
+func TestTelemetryPacketTruncated(t *testing.T) {
+	p := TelemetryPacket{RoverID: 3, Battery: 80, QueueP1Count: 1, QueueP2Count: 2, QueueP3Count: 3}
+	encoded := p.Encode()
+
+	var got TelemetryPacket
+	if err := got.Decode(encoded[:TelemetryPacketMinSize]); err != nil {
+		t.Fatalf("packet without queue counts: %v", err)
+	}
+	if got.RoverID != 3 || got.Battery != 80 || got.QueueP1Count != 0 {
+		t.Errorf("packet without queue counts decoded as %+v", got)
+	}
+	for n := 0; n < TelemetryPacketMinSize; n++ {
+		if err := got.Decode(encoded[:n]); err == nil {
+			t.Errorf("%d bytes decoded without error", n)
+		}
+	}
+}
+
+// Seed inputs live in testdata/fuzz/FuzzTelemetryPacketDecode
+func FuzzTelemetryPacketDecode(f *testing.F) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var p TelemetryPacket
+		if p.Decode(data) != nil {
+			return
+		}
+		encoded := p.Encode()
+		var again TelemetryPacket
+		if err := again.Decode(encoded); err != nil {
+			t.Fatalf("decoding re-encoded %x: %v", encoded, err)
+		}
+		if reencoded := again.Encode(); !bytes.Equal(reencoded, encoded) {
+			t.Fatalf("encoding not stable: %x, then %x", encoded, reencoded)
+		}
+	})
+}
@@ -4,12 +4,24 @@ import (
 	"fmt"
 )
 
+// Bounds of the map: coordinates are normalized to [MAP_MIN, MAP_MAX] on both axes
+const (
+	MAP_MIN = -1.0
+	MAP_MAX = 1.0
+)
+
 // Coordinate represents a geographical coordinate with latitude and longitude.
 type Coordinate struct {
 	Latitude  float64 `json:"latitude"`  // ex: 41.545
 	Longitude float64 `json:"longitude"` // ex: -8.421
 }
 
+// InMap reports whether the coordinate lies inside the map
+func (c Coordinate) InMap() bool {
+	return c.Latitude >= MAP_MIN && c.Latitude <= MAP_MAX &&
+		c.Longitude >= MAP_MIN && c.Longitude <= MAP_MAX
+}
+
 // String returns a human-readable representation of the coordinate.
 func (c Coordinate) String() string {
 	return fmt.Sprintf("(%.6f, %.6f)", c.Latitude, c.Longitude)
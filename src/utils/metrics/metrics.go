@@ -23,6 +23,8 @@ type MLMetrics struct {
 
 	// Error metrics
 	ChecksumsFailed    uint64
+	AuthFailures       uint64 // Packets rejected by MAC verification
+	VersionsRejected   uint64 // Packets refused due to an unsupported protocol version
 	Retransmissions    uint64
 	PacketsLost        uint64 // Packets that exceeded max retries
 	DuplicatesReceived uint64
@@ -30,6 +32,26 @@ type MLMetrics struct {
 	// Out-of-order metrics
 	OutOfOrderReceived uint64
 	BufferedPackets    uint64
+	PacketsSacked      uint64 // In-flight packets released early by SACK blocks
+	ReorderDrops       uint64 // Out-of-order packets dropped because the reorder buffer was full
+
+	// Fragmentation metrics
+	MessagesFragmented  uint64 // Messages split into fragments because they exceeded the MTU
+	MessagesReassembled uint64 // Fragmented messages rebuilt from the peer's fragments
+
+	// Compression metrics
+	MessagesCompressed uint64 // Messages sent with a compressed payload
+	BytesUncompressed  uint64 // Payload bytes of those messages before compression
+	BytesCompressed    uint64 // Payload bytes of those messages after compression
+
+	// Path migration metrics
+	PathChallenges uint64 // Path challenges sent to a new peer address
+	PathMigrations uint64 // Peers moved to a new address after a valid path response
+
+	// Forward error correction metrics
+	FecParitySent          uint64 // MSG_FEC parity packets sent
+	ChunksRecoveredFEC     uint64 // Lost packets rebuilt from a parity packet
+	ChunksRecoveredRetrans uint64 // Packets of a parity group that could only be recovered by retransmission
 
 	// Timing metrics
 	TotalRTT   time.Duration
@@ -37,6 +59,9 @@ type MLMetrics struct {
 	MinRTT     time.Duration
 	MaxRTT     time.Duration
 
+	// Congestion control tracking, by peer (every session has its own window)
+	Congestion map[uint8]*CwndStats
+
 	// Throughput tracking
 	BytesSent     uint64
 	BytesReceived uint64
@@ -46,6 +71,26 @@ type MLMetrics struct {
 	PacketTypesReceived map[string]uint64
 }
 
+// CwndStats tracks the congestion window of the session with one peer
+type CwndStats struct {
+	Cwnd      float64      // Last congestion window (packets)
+	Ssthresh  float64      // Last slow start threshold (packets)
+	MaxCwnd   float64      // Largest congestion window observed
+	TotalCwnd float64      // Sum of cwnd samples (for the average)
+	Samples   uint64       // Number of cwnd samples
+	Trace     []CwndSample // Time series of cwnd changes (capped at maxCwndTrace)
+}
+
+// CwndSample is a point of the congestion window time series
+type CwndSample struct {
+	ElapsedMs int64   `json:"elapsed_ms"` // Milliseconds since metrics collection started
+	Cwnd      float64 `json:"cwnd"`
+	Ssthresh  float64 `json:"ssthresh"`
+}
+
+// maxCwndTrace caps the number of cwnd samples kept for plotting, per peer
+const maxCwndTrace = 10000
+
 // NewMetricsManager creates a new metrics manager
 func NewMetricsManager(enabled bool) *MLMetrics {
 	return &MLMetrics{
@@ -54,6 +99,7 @@ func NewMetricsManager(enabled bool) *MLMetrics {
 		MinRTT:              time.Hour, // Start high for proper min tracking
 		PacketTypesSent:     make(map[string]uint64),
 		PacketTypesReceived: make(map[string]uint64),
+		Congestion:          make(map[uint8]*CwndStats),
 	}
 }
 
@@ -129,6 +175,22 @@ func (m *MLMetrics) RecordChecksumFailed() {
 	atomic.AddUint64(&m.ChecksumsFailed, 1)
 }
 
+// RecordAuthFailed records a packet rejected by MAC verification
+func (m *MLMetrics) RecordAuthFailed() {
+	if !m.enabled {
+		return
+	}
+	atomic.AddUint64(&m.AuthFailures, 1)
+}
+
+// RecordVersionRejected records a packet refused due to an unsupported protocol version
+func (m *MLMetrics) RecordVersionRejected() {
+	if !m.enabled {
+		return
+	}
+	atomic.AddUint64(&m.VersionsRejected, 1)
+}
+
 // RecordRetransmission records a packet retransmission
 func (m *MLMetrics) RecordRetransmission() {
 	if !m.enabled {
@@ -161,6 +223,128 @@ func (m *MLMetrics) RecordOutOfOrder() {
 	atomic.AddUint64(&m.OutOfOrderReceived, 1)
 }
 
+// RecordSackedPacket records an in-flight packet released by a SACK block
+func (m *MLMetrics) RecordSackedPacket() {
+	if !m.enabled {
+		return
+	}
+	atomic.AddUint64(&m.PacketsSacked, 1)
+}
+
+// RecordReorderDrop records an out-of-order packet dropped by a full reorder buffer
+func (m *MLMetrics) RecordReorderDrop() {
+	if !m.enabled {
+		return
+	}
+	atomic.AddUint64(&m.ReorderDrops, 1)
+}
+
+// RecordCompression records a payload compressed from before to after bytes
+func (m *MLMetrics) RecordCompression(before, after int) {
+	if !m.enabled {
+		return
+	}
+	atomic.AddUint64(&m.MessagesCompressed, 1)
+	atomic.AddUint64(&m.BytesUncompressed, uint64(before))
+	atomic.AddUint64(&m.BytesCompressed, uint64(after))
+}
+
+// GetCompressionRatio returns uncompressed / compressed size of the compressed payloads
+func (m *MLMetrics) GetCompressionRatio() float64 {
+	before := atomic.LoadUint64(&m.BytesUncompressed)
+	after := atomic.LoadUint64(&m.BytesCompressed)
+
+	if after == 0 {
+		return 0
+	}
+	return float64(before) / float64(after)
+}
+
+// RecordPathChallenge records a path challenge sent to a new peer address
+func (m *MLMetrics) RecordPathChallenge() {
+	if !m.enabled {
+		return
+	}
+	atomic.AddUint64(&m.PathChallenges, 1)
+}
+
+// RecordPathMigration records a peer migrated to a validated new address
+func (m *MLMetrics) RecordPathMigration() {
+	if !m.enabled {
+		return
+	}
+	atomic.AddUint64(&m.PathMigrations, 1)
+}
+
+// RecordFecParitySent records a parity packet sent
+func (m *MLMetrics) RecordFecParitySent() {
+	if !m.enabled {
+		return
+	}
+	atomic.AddUint64(&m.FecParitySent, 1)
+}
+
+// RecordChunkRecoveredFEC records a lost packet rebuilt from a parity packet
+func (m *MLMetrics) RecordChunkRecoveredFEC() {
+	if !m.enabled {
+		return
+	}
+	atomic.AddUint64(&m.ChunksRecoveredFEC, 1)
+}
+
+// RecordChunkRecoveredRetrans records a packet the parity couldn't rebuild, received once retransmitted
+func (m *MLMetrics) RecordChunkRecoveredRetrans() {
+	if !m.enabled {
+		return
+	}
+	atomic.AddUint64(&m.ChunksRecoveredRetrans, 1)
+}
+
+// RecordMessageFragmented records a message split into fragments
+func (m *MLMetrics) RecordMessageFragmented() {
+	if !m.enabled {
+		return
+	}
+	atomic.AddUint64(&m.MessagesFragmented, 1)
+}
+
+// RecordMessageReassembled records a message rebuilt from its fragments
+func (m *MLMetrics) RecordMessageReassembled() {
+	if !m.enabled {
+		return
+	}
+	atomic.AddUint64(&m.MessagesReassembled, 1)
+}
+
+// RecordCwnd records a congestion window change of the session with a peer
+// (the rover's ID on the MotherShip, 0 on a rover)
+func (m *MLMetrics) RecordCwnd(peer uint8, cwnd, ssthresh float64) {
+	if !m.enabled {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.Congestion[peer]
+	if stats == nil {
+		stats = &CwndStats{}
+		m.Congestion[peer] = stats
+	}
+	stats.Cwnd = cwnd
+	stats.Ssthresh = ssthresh
+	stats.TotalCwnd += cwnd
+	stats.Samples++
+	if cwnd > stats.MaxCwnd {
+		stats.MaxCwnd = cwnd
+	}
+	if len(stats.Trace) < maxCwndTrace {
+		stats.Trace = append(stats.Trace, CwndSample{
+			ElapsedMs: time.Since(m.startTime).Milliseconds(),
+			Cwnd:      cwnd,
+			Ssthresh:  ssthresh,
+		})
+	}
+}
 
 // RecordRTT records a Round-Trip Time sample
 func (m *MLMetrics) RecordRTT(rtt time.Duration) {
@@ -248,28 +432,51 @@ func (m *MLMetrics) GetUptime() time.Duration {
 
 // Summary returns a summary of all metrics
 type MetricsSummary struct {
-	Uptime              string            `json:"uptime"`
-	PacketsSent         uint64            `json:"packets_sent"`
-	PacketsReceived     uint64            `json:"packets_received"`
-	AcksSent            uint64            `json:"acks_sent"`
-	AcksReceived        uint64            `json:"acks_received"`
-	ChecksumsFailed     uint64            `json:"checksums_failed"`
-	Retransmissions     uint64            `json:"retransmissions"`
-	PacketsLost         uint64            `json:"packets_lost"`
-	DuplicatesReceived  uint64            `json:"duplicates_received"`
-	OutOfOrderReceived  uint64            `json:"out_of_order_received"`
-	BytesSent           uint64            `json:"bytes_sent"`
-	BytesReceived       uint64            `json:"bytes_received"`
-	AvgRTT              string            `json:"avg_rtt"`
-	MinRTT              string            `json:"min_rtt"`
-	MaxRTT              string            `json:"max_rtt"`
-	PacketLossRate      float64           `json:"packet_loss_rate_percent"`
-	RetransmissionRate  float64           `json:"retransmission_rate_percent"`
-	DuplicateRate       float64           `json:"duplicate_rate_percent"`
-	ThroughputSentBps   float64           `json:"throughput_sent_bps"`
-	ThroughputRecvBps   float64           `json:"throughput_recv_bps"`
-	PacketTypesSent     map[string]uint64 `json:"packet_types_sent"`
-	PacketTypesReceived map[string]uint64 `json:"packet_types_received"`
+	Uptime              string                      `json:"uptime"`
+	PacketsSent         uint64                      `json:"packets_sent"`
+	PacketsReceived     uint64                      `json:"packets_received"`
+	AcksSent            uint64                      `json:"acks_sent"`
+	AcksReceived        uint64                      `json:"acks_received"`
+	ChecksumsFailed     uint64                      `json:"checksums_failed"`
+	AuthFailures        uint64                      `json:"auth_failures"`
+	VersionsRejected    uint64                      `json:"versions_rejected"`
+	Retransmissions     uint64                      `json:"retransmissions"`
+	PacketsLost         uint64                      `json:"packets_lost"`
+	DuplicatesReceived  uint64                      `json:"duplicates_received"`
+	OutOfOrderReceived  uint64                      `json:"out_of_order_received"`
+	PacketsSacked       uint64                      `json:"packets_sacked"`
+	ReorderDrops        uint64                      `json:"reorder_drops"`
+	MessagesFragmented  uint64                      `json:"messages_fragmented"`
+	MessagesReassembled uint64                      `json:"messages_reassembled"`
+	MessagesCompressed  uint64                      `json:"messages_compressed"`
+	CompressionRatio    float64                     `json:"compression_ratio"`
+	PathChallenges      uint64                      `json:"path_challenges"`
+	PathMigrations      uint64                      `json:"path_migrations"`
+	FecParitySent       uint64                      `json:"fec_parity_sent"`
+	ChunksRecoveredFEC  uint64                      `json:"chunks_recovered_fec"`
+	ChunksRecoveredRtx  uint64                      `json:"chunks_recovered_retransmission"`
+	BytesSent           uint64                      `json:"bytes_sent"`
+	BytesReceived       uint64                      `json:"bytes_received"`
+	AvgRTT              string                      `json:"avg_rtt"`
+	MinRTT              string                      `json:"min_rtt"`
+	MaxRTT              string                      `json:"max_rtt"`
+	PacketLossRate      float64                     `json:"packet_loss_rate_percent"`
+	RetransmissionRate  float64                     `json:"retransmission_rate_percent"`
+	DuplicateRate       float64                     `json:"duplicate_rate_percent"`
+	ThroughputSentBps   float64                     `json:"throughput_sent_bps"`
+	ThroughputRecvBps   float64                     `json:"throughput_recv_bps"`
+	Congestion          map[uint8]CongestionSummary `json:"congestion"`
+	PacketTypesSent     map[string]uint64           `json:"packet_types_sent"`
+	PacketTypesReceived map[string]uint64           `json:"packet_types_received"`
+}
+
+// CongestionSummary holds the congestion window metrics of the session with one peer
+type CongestionSummary struct {
+	Cwnd      float64      `json:"cwnd"`
+	Ssthresh  float64      `json:"ssthresh"`
+	AvgCwnd   float64      `json:"avg_cwnd"`
+	MaxCwnd   float64      `json:"max_cwnd"`
+	CwndTrace []CwndSample `json:"cwnd_trace"`
 }
 
 // GetSummary returns a complete summary of metrics
@@ -293,6 +500,20 @@ func (m *MLMetrics) GetSummary() MetricsSummary {
 	for k, v := range m.PacketTypesReceived {
 		typesReceived[k] = v
 	}
+	congestion := make(map[uint8]CongestionSummary, len(m.Congestion))
+	for peer, stats := range m.Congestion {
+		avgCwnd := 0.0
+		if stats.Samples > 0 {
+			avgCwnd = stats.TotalCwnd / float64(stats.Samples)
+		}
+		congestion[peer] = CongestionSummary{
+			Cwnd:      stats.Cwnd,
+			Ssthresh:  stats.Ssthresh,
+			AvgCwnd:   avgCwnd,
+			MaxCwnd:   stats.MaxCwnd,
+			CwndTrace: append([]CwndSample(nil), stats.Trace...),
+		}
+	}
 
 	return MetricsSummary{
 		Uptime:              m.GetUptime().Round(time.Second).String(),
@@ -301,10 +522,23 @@ func (m *MLMetrics) GetSummary() MetricsSummary {
 		AcksSent:            atomic.LoadUint64(&m.AcksSent),
 		AcksReceived:        atomic.LoadUint64(&m.AcksReceived),
 		ChecksumsFailed:     atomic.LoadUint64(&m.ChecksumsFailed),
+		AuthFailures:        atomic.LoadUint64(&m.AuthFailures),
+		VersionsRejected:    atomic.LoadUint64(&m.VersionsRejected),
 		Retransmissions:     atomic.LoadUint64(&m.Retransmissions),
 		PacketsLost:         atomic.LoadUint64(&m.PacketsLost),
 		DuplicatesReceived:  atomic.LoadUint64(&m.DuplicatesReceived),
 		OutOfOrderReceived:  atomic.LoadUint64(&m.OutOfOrderReceived),
+		PacketsSacked:       atomic.LoadUint64(&m.PacketsSacked),
+		ReorderDrops:        atomic.LoadUint64(&m.ReorderDrops),
+		MessagesFragmented:  atomic.LoadUint64(&m.MessagesFragmented),
+		MessagesReassembled: atomic.LoadUint64(&m.MessagesReassembled),
+		MessagesCompressed:  atomic.LoadUint64(&m.MessagesCompressed),
+		CompressionRatio:    m.GetCompressionRatio(),
+		PathChallenges:      atomic.LoadUint64(&m.PathChallenges),
+		PathMigrations:      atomic.LoadUint64(&m.PathMigrations),
+		FecParitySent:       atomic.LoadUint64(&m.FecParitySent),
+		ChunksRecoveredFEC:  atomic.LoadUint64(&m.ChunksRecoveredFEC),
+		ChunksRecoveredRtx:  atomic.LoadUint64(&m.ChunksRecoveredRetrans),
 		BytesSent:           atomic.LoadUint64(&m.BytesSent),
 		BytesReceived:       atomic.LoadUint64(&m.BytesReceived),
 		AvgRTT:              m.GetAverageRTT().Round(time.Microsecond).String(),
@@ -315,6 +549,7 @@ func (m *MLMetrics) GetSummary() MetricsSummary {
 		DuplicateRate:       m.GetDuplicateRate(),
 		ThroughputSentBps:   sentBps,
 		ThroughputRecvBps:   recvBps,
+		Congestion:          congestion,
 		PacketTypesSent:     typesSent,
 		PacketTypesReceived: typesReceived,
 	}
@@ -347,11 +582,25 @@ func (m *MLMetrics) Reset() {
 	atomic.StoreUint64(&m.AcksSent, 0)
 	atomic.StoreUint64(&m.AcksReceived, 0)
 	atomic.StoreUint64(&m.ChecksumsFailed, 0)
+	atomic.StoreUint64(&m.AuthFailures, 0)
+	atomic.StoreUint64(&m.VersionsRejected, 0)
 	atomic.StoreUint64(&m.Retransmissions, 0)
 	atomic.StoreUint64(&m.PacketsLost, 0)
 	atomic.StoreUint64(&m.DuplicatesReceived, 0)
 	atomic.StoreUint64(&m.OutOfOrderReceived, 0)
 	atomic.StoreUint64(&m.BufferedPackets, 0)
+	atomic.StoreUint64(&m.PacketsSacked, 0)
+	atomic.StoreUint64(&m.ReorderDrops, 0)
+	atomic.StoreUint64(&m.MessagesFragmented, 0)
+	atomic.StoreUint64(&m.MessagesReassembled, 0)
+	atomic.StoreUint64(&m.MessagesCompressed, 0)
+	atomic.StoreUint64(&m.BytesUncompressed, 0)
+	atomic.StoreUint64(&m.BytesCompressed, 0)
+	atomic.StoreUint64(&m.PathChallenges, 0)
+	atomic.StoreUint64(&m.PathMigrations, 0)
+	atomic.StoreUint64(&m.FecParitySent, 0)
+	atomic.StoreUint64(&m.ChunksRecoveredFEC, 0)
+	atomic.StoreUint64(&m.ChunksRecoveredRetrans, 0)
 	atomic.StoreUint64(&m.BytesSent, 0)
 	atomic.StoreUint64(&m.BytesReceived, 0)
 
@@ -359,6 +608,7 @@ func (m *MLMetrics) Reset() {
 	m.RTTSamples = 0
 	m.MinRTT = time.Hour
 	m.MaxRTT = 0
+	m.Congestion = make(map[uint8]*CwndStats)
 	m.startTime = time.Now()
 	m.PacketTypesSent = make(map[string]uint64)
 	m.PacketTypesReceived = make(map[string]uint64)
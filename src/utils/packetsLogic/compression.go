@@ -0,0 +1,43 @@
+package packetslogic
+
+import (
+	"src/config"
+	"src/internal/ml"
+	"src/utils/metrics"
+)
+
+// Payload compression.
+// Messages of the types listed in COMPRESSION_TYPES are compressed with the window's Codec
+// before fragmentation and flagged with ml.FLAG_COMPRESSED (every fragment carries the flag).
+// The receiver decompresses them once delivered in order and reassembled.
+
+// compress returns the compressed payload of an outgoing message, if it is worth it:
+// the peer negotiated CAP_COMPRESSION, the type is configured, the payload reaches the
+// threshold and actually shrinks
+func (w *Window) compress(msgType ml.PacketType, payload []byte) ([]byte, bool) {
+	if !w.HasCapability(ml.CAP_COMPRESSION) || !config.COMPRESSION_TYPES[msgType.String()] ||
+		len(payload) < config.COMPRESSION_THRESHOLD {
+		return payload, false
+	}
+
+	compressed, err := w.Codec.Compress(payload)
+	if err != nil || len(compressed) >= len(payload) {
+		return payload, false // Already dense (e.g. JPEG chunks)
+	}
+
+	if m := metrics.GetGlobalMetrics(); m != nil {
+		m.RecordCompression(len(payload), len(compressed))
+	}
+	return compressed, true
+}
+
+// decompress restores the payload of a delivered message flagged with ml.FLAG_COMPRESSED
+func (w *Window) decompress(pkt ml.Packet) (ml.Packet, error) {
+	payload, err := w.Codec.Decompress(pkt.Payload)
+	if err != nil {
+		return pkt, err
+	}
+	pkt.Payload = payload
+	pkt.Flags &^= ml.FLAG_COMPRESSED
+	return pkt, nil
+}
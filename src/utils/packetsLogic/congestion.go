@@ -0,0 +1,87 @@
+package packetslogic
+
+import (
+	"math"
+	"src/config"
+	"src/utils/metrics"
+)
+
+// NewReno-style congestion control (RFC 5681 / RFC 6582) for the sliding window.
+// The congestion window (Cwnd) and slow start threshold (Ssthresh) are measured in packets.
+// The effective send limit is min(Cwnd, MAX_PACKETS_IN_FLIGHT).
+
+// minSsthresh is the lowest slow start threshold allowed after a loss
+const minSsthresh = 2.0
+
+// congestionLimit returns how many packets may currently be in flight
+// Caller must hold w.Mu
+func (w *Window) congestionLimit() int {
+	limit := int(math.Floor(w.Cwnd))
+	if limit < 1 {
+		limit = 1
+	}
+	if limit > config.MAX_PACKETS_IN_FLIGHT {
+		limit = config.MAX_PACKETS_IN_FLIGHT
+	}
+	return limit
+}
+
+// growCwnd grows the congestion window for one newly acknowledged packet:
+// exponentially during slow start, by ~1 packet per RTT in congestion avoidance
+// Caller must hold w.Mu
+func (w *Window) growCwnd() {
+	if w.Cwnd < w.Ssthresh {
+		w.Cwnd++ // Slow start
+	} else {
+		w.Cwnd += 1 / w.Cwnd // Congestion avoidance
+	}
+	// Growing beyond the static cap would only inflate the window after a burst
+	if maxCwnd := float64(config.MAX_PACKETS_IN_FLIGHT); w.Cwnd > maxCwnd {
+		w.Cwnd = maxCwnd
+	}
+	w.recordCwnd()
+}
+
+// shrinkCwnd reacts to the loss of the packet with sequence number seq
+// Both timeouts and fast retransmits halve ssthresh; a timeout restarts slow start
+// from the initial window while fast retransmit continues from the halved window.
+// Only one reduction happens per window of data (losses below RecoverSeq are ignored).
+// Caller must hold w.Mu
+func (w *Window) shrinkCwnd(seq uint32, timeout bool) {
+	if w.InRecovery && seqLessThan(seq, w.RecoverSeq) {
+		return // Same loss event, window already reduced
+	}
+
+	w.Ssthresh = math.Max(w.Cwnd/2, minSsthresh)
+	if timeout {
+		w.Cwnd = float64(config.INITIAL_CWND)
+	} else {
+		w.Cwnd = w.Ssthresh
+	}
+	w.InRecovery = true
+	w.RecoverSeq = w.HighestSent
+	w.recordCwnd()
+}
+
+// exitRecovery leaves loss recovery once every packet sent before the loss is acknowledged
+// Caller must hold w.Mu
+func (w *Window) exitRecovery(ackNum uint32) {
+	if w.InRecovery && seqGreaterThan(ackNum, w.RecoverSeq) {
+		w.InRecovery = false
+	}
+}
+
+// recordCwnd exports the current congestion state to metrics, under the peer's ID
+// Caller must hold w.Mu
+func (w *Window) recordCwnd() {
+	if m := metrics.GlobalMetrics; m != nil {
+		m.RecordCwnd(w.Peer, w.Cwnd, w.Ssthresh)
+	}
+}
+
+// GetCwnd returns the current congestion window and slow start threshold
+func (w *Window) GetCwnd() (cwnd, ssthresh float64) {
+	w.Mu.Lock()
+	defer w.Mu.Unlock()
+	return w.Cwnd, w.Ssthresh
+}
@@ -0,0 +1,229 @@
+package packetslogic
+
+import (
+	"net"
+	"src/config"
+	"src/internal/ml"
+	"src/utils/metrics"
+)
+
+// Forward error correction of report streams.
+// While a stream is protected (EnableFEC), every FEC_GROUP_SIZE report packets are followed
+// by an unsequenced MSG_FEC carrying their XOR parity (see ml.FecData). The receiver keeps
+// the wire payloads of the peer's latest packets: when a single packet of a group is lost,
+// it is rebuilt from the parity and the others, and goes through decryption and ordering
+// like any other packet, so the cumulative ACK covers it before the sender's RTO expires.
+// Groups missing more packets wait for retransmissions until only one is left.
+
+// fecHistory bounds how many received payloads are kept to rebuild lost packets
+const fecHistory = 4 * ml.MAX_FEC_GROUP
+
+// fecMaxPending bounds how many parity groups may wait for retransmissions at once
+const fecMaxPending = 8
+
+// fecEncoder builds the parity groups of a protected stream
+type fecEncoder struct {
+	size    int         // Packets per parity group
+	packets []ml.Packet // Sealed packets of the group being built
+	conn    PacketConn
+	addr    *net.UDPAddr
+	logf    Logger
+}
+
+// fecGroup is a parity group received from the peer that still misses packets
+type fecGroup struct {
+	data    ml.FecData
+	version uint8
+	roverID uint8
+	missing map[uint32]bool // SeqNums not received when the parity arrived
+}
+
+// EnableFEC starts protecting the report packets sent next with parity packets, one every
+// FEC_GROUP_SIZE reports. Does nothing unless the peer negotiated CAP_FEC
+func (w *Window) EnableFEC() {
+	w.Mu.Lock()
+	defer w.Mu.Unlock()
+	if w.Capabilities&ml.CAP_FEC == 0 || w.fecOut != nil {
+		return
+	}
+	w.fecOut = &fecEncoder{size: config.FEC_GROUP_SIZE}
+}
+
+// DisableFEC stops protecting reports, sending the parity of the last (partial) group
+func (w *Window) DisableFEC() {
+	w.Mu.Lock()
+	enc := w.fecOut
+	w.fecOut = nil
+	w.Mu.Unlock()
+
+	if enc != nil && len(enc.packets) > 0 {
+		w.sendParity(enc.conn, enc.addr, enc.packets, enc.logf)
+	}
+}
+
+// protect adds a report packet that was just sent to the parity group being built,
+// sending the group's parity once it is complete
+// Packets whose parity wouldn't fit in the MTU are left to retransmissions
+func (w *Window) protect(conn PacketConn, addr *net.UDPAddr, pkt ml.Packet, logf Logger) {
+	if pkt.MsgType != ml.MSG_REPORT {
+		return // Rebuilt packets are acknowledged like reports
+	}
+
+	w.Mu.Lock()
+	enc := w.fecOut
+	if enc == nil {
+		w.Mu.Unlock()
+		return
+	}
+	sealed := sealPacket(pkt, w.Keys())
+	if ml.PacketHeaderSize+ml.FecDataSize(enc.size, len(sealed.Payload)) > config.MTU {
+		w.Mu.Unlock()
+		return
+	}
+	enc.packets = append(enc.packets, sealed)
+	enc.conn, enc.addr, enc.logf = conn, addr, logf
+
+	var group []ml.Packet
+	if len(enc.packets) == enc.size {
+		group = enc.packets
+		enc.packets = nil
+	}
+	w.Mu.Unlock()
+
+	if group != nil {
+		w.sendParity(conn, addr, group, logf)
+	}
+}
+
+// sendParity sends the MSG_FEC protecting a group of sealed packets
+func (w *Window) sendParity(conn PacketConn, addr *net.UDPAddr, group []ml.Packet, logf Logger) {
+	data := ml.NewFecData(group)
+	pkt := ml.Packet{
+		RoverId: group[0].RoverId,
+		MsgType: ml.MSG_FEC,
+		Payload: data.Encode(),
+	}
+	sendUnsequencedPacket(conn, addr, pkt, w.Keys(), logf)
+
+	if m := metrics.GetGlobalMetrics(); m != nil {
+		m.RecordFecParitySent()
+	}
+}
+
+// receiveFEC records an authenticated packet from the peer (before decryption) and returns
+// the lost packets it allowed to rebuild: a MSG_FEC may rebuild the single packet missing
+// from its group, and a retransmitted packet may leave a pending group with a single one
+func (w *Window) receiveFEC(pkt ml.Packet, logf Logger) []ml.Packet {
+	w.Mu.Lock()
+	defer w.Mu.Unlock()
+	if w.Capabilities&ml.CAP_FEC == 0 {
+		return nil
+	}
+
+	if pkt.MsgType == ml.MSG_FEC {
+		var data ml.FecData
+		if err := data.Decode(pkt.Payload); err != nil {
+			logf("WARN", "Malformed FEC payload ignored", map[string]any{"error": err})
+			return nil
+		}
+		group := &fecGroup{data: data, version: pkt.Version, roverID: pkt.RoverId, missing: make(map[uint32]bool)}
+		for _, entry := range data.Entries {
+			if _, ok := w.fecSeen[entry.SeqNum]; !ok {
+				group.missing[entry.SeqNum] = true
+			}
+		}
+		if len(group.missing) == 0 {
+			return nil
+		}
+		if rebuilt, ok := w.rebuild(group, logf); ok {
+			return []ml.Packet{rebuilt}
+		}
+		if len(w.fecPending) == fecMaxPending {
+			w.fecPending = w.fecPending[1:]
+		}
+		w.fecPending = append(w.fecPending, group)
+		return nil
+	}
+
+	if pkt.MsgType.IsUnsequenced() {
+		return nil
+	}
+	if _, ok := w.fecSeen[pkt.SeqNum]; ok {
+		return nil // Duplicate
+	}
+	w.remember(pkt.SeqNum, pkt.Payload)
+
+	var recovered []ml.Packet
+	pending := w.fecPending[:0]
+	for _, group := range w.fecPending {
+		if group.missing[pkt.SeqNum] {
+			delete(group.missing, pkt.SeqNum)
+			if m := metrics.GetGlobalMetrics(); m != nil {
+				m.RecordChunkRecoveredRetrans()
+			}
+		}
+		if rebuilt, ok := w.rebuild(group, logf); ok {
+			recovered = append(recovered, rebuilt)
+			continue
+		}
+		if len(group.missing) > 0 {
+			pending = append(pending, group)
+		}
+	}
+	w.fecPending = pending
+	return recovered
+}
+
+// rebuild recovers the packet missing from a group, if it is the only one
+// Caller must hold w.Mu
+func (w *Window) rebuild(group *fecGroup, logf Logger) (ml.Packet, bool) {
+	if len(group.missing) != 1 {
+		return ml.Packet{}, false
+	}
+
+	for i, entry := range group.data.Entries {
+		if !group.missing[entry.SeqNum] {
+			continue
+		}
+		rebuilt, err := group.data.Recover(i, w.fecSeen)
+		if err != nil {
+			return ml.Packet{}, false // A payload of the group was already forgotten
+		}
+		rebuilt.Version = group.version
+		rebuilt.RoverId = group.roverID
+
+		delete(group.missing, entry.SeqNum)
+		w.remember(rebuilt.SeqNum, rebuilt.Payload)
+		if m := metrics.GetGlobalMetrics(); m != nil {
+			m.RecordChunkRecoveredFEC()
+		}
+		logf("INFO", "Lost packet rebuilt from FEC parity", map[string]any{
+			"seq":  rebuilt.SeqNum,
+			"type": rebuilt.MsgType.String(),
+		})
+		return rebuilt, true
+	}
+	return ml.Packet{}, false
+}
+
+// remember keeps the wire payload of a received packet, forgetting the oldest one when full
+// Caller must hold w.Mu
+func (w *Window) remember(seqNum uint32, payload []byte) {
+	if len(w.fecSeenOrder) == fecHistory {
+		delete(w.fecSeen, w.fecSeenOrder[0])
+		w.fecSeenOrder = w.fecSeenOrder[1:]
+	}
+	w.fecSeen[seqNum] = append([]byte(nil), payload...)
+	w.fecSeenOrder = append(w.fecSeenOrder, seqNum)
+}
+
+// resetFEC forgets every parity group, sent or received
+// Caller must hold w.Mu
+func (w *Window) resetFEC() {
+	if w.fecOut != nil {
+		w.fecOut.packets = nil
+	}
+	w.fecSeen = make(map[uint32][]byte)
+	w.fecSeenOrder = nil
+	w.fecPending = nil
+}
@@ -0,0 +1,172 @@
+package packetslogic
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"src/config"
+	"src/internal/ml"
+	"src/utils/metrics"
+	"sync"
+)
+
+// Fragmentation of messages larger than the MTU.
+// A payload that doesn't fit in one datagram is split into fragments flagged with
+// ml.FLAG_FRAGMENT, each starting with an ml.FragmentHeader. Every fragment is a regular
+// sequenced packet (window, retransmission, ACKs), so fragments arrive in order and the
+// receiver only has to concatenate them once the last one is delivered.
+
+// maxPartialMessages bounds how many messages a peer may have half-delivered at once
+const maxPartialMessages = 16
+
+// partialMessage collects the fragments of a message being reassembled
+type partialMessage struct {
+	first    ml.Packet // Fragment 0 (header fields of the rebuilt packet)
+	parts    [][]byte
+	received int
+}
+
+// maxFragmentData returns how many payload bytes fit in one fragment
+func (w *Window) maxFragmentData() int {
+	return config.MTU - ml.PacketHeaderSize - w.Keys().Cipher.Overhead() - ml.FRAGMENT_HEADER_SIZE
+}
+
+// needsFragmentation reports whether a payload must be split to fit in the MTU
+// Peers that didn't negotiate CAP_FRAGMENTATION always get the payload in one datagram
+func (w *Window) needsFragmentation(msgType ml.PacketType, payload []byte) bool {
+	if msgType.IsUnsequenced() || !w.HasCapability(ml.CAP_FRAGMENTATION) {
+		return false
+	}
+	return ml.PacketHeaderSize+w.Keys().Cipher.Overhead()+len(payload) > config.MTU
+}
+
+// sendFragmented splits payload into fragments and sends them one after the other
+// Only the first fragment carries ackNum, so the peer doesn't count duplicate ACKs
+func sendFragmented(
+	ctx context.Context,
+	conn PacketConn,
+	addr *net.UDPAddr,
+	roverID uint8,
+	msgType ml.PacketType,
+	flags uint8,
+	seqNum *uint32,
+	ackNum uint32,
+	payload []byte,
+	message []byte,
+	window *Window,
+	windowLock *sync.Mutex,
+	logf Logger,
+) error {
+	size := window.maxFragmentData()
+	total := (len(payload) + size - 1) / size
+	if total > ml.MAX_FRAGMENTS {
+		return fmt.Errorf("message of %d bytes needs %d fragments (max %d)", len(payload), total, ml.MAX_FRAGMENTS)
+	}
+
+	window.Mu.Lock()
+	messageID := window.nextMessageID
+	window.nextMessageID++
+	window.Mu.Unlock()
+
+	logf("INFO", "Message fragmented", map[string]any{
+		"type":      msgType.String(),
+		"messageId": messageID,
+		"size":      len(payload),
+		"fragments": total,
+	})
+	if m := metrics.GetGlobalMetrics(); m != nil {
+		m.RecordMessageFragmented()
+	}
+
+	for i := 0; i < total; i++ {
+		end := (i + 1) * size
+		if end > len(payload) {
+			end = len(payload)
+		}
+		header := ml.FragmentHeader{MessageID: messageID, Index: uint16(i), Total: uint16(total)}
+		err := sendSegment(ctx, conn, addr, roverID, msgType, flags|ml.FLAG_FRAGMENT, seqNum, ackNum,
+			header.Encode(payload[i*size:end]), message, window, windowLock, logf)
+		if err != nil {
+			return err
+		}
+		ackNum = 0
+	}
+	return nil
+}
+
+// reassemble stores a delivered fragment and returns the rebuilt packet once complete
+// Fragments must be delivered in sequence order (see HandleOrderedPacket)
+func (w *Window) reassemble(pkt ml.Packet) (ml.Packet, bool, error) {
+	var header ml.FragmentHeader
+	data, err := header.Decode(pkt.Payload)
+	if err != nil {
+		return ml.Packet{}, false, err
+	}
+
+	w.Mu.Lock()
+	defer w.Mu.Unlock()
+
+	msg, exists := w.fragments[header.MessageID]
+	if !exists {
+		if len(w.fragments) >= maxPartialMessages {
+			return ml.Packet{}, false, fmt.Errorf("too many partial messages (%d)", len(w.fragments))
+		}
+		msg = &partialMessage{parts: make([][]byte, header.Total)}
+		w.fragments[header.MessageID] = msg
+	}
+	if int(header.Total) != len(msg.parts) || msg.parts[header.Index] != nil {
+		return ml.Packet{}, false, fmt.Errorf("fragment %d/%d doesn't match message %d", header.Index, header.Total, header.MessageID)
+	}
+
+	if header.Index == 0 {
+		msg.first = pkt
+	}
+	msg.parts[header.Index] = data
+	msg.received++
+	if msg.received < len(msg.parts) {
+		return ml.Packet{}, false, nil
+	}
+
+	// Every fragment arrived: rebuild the original packet
+	delete(w.fragments, header.MessageID)
+	full := msg.first
+	full.Flags &^= ml.FLAG_FRAGMENT
+	full.Payload = nil
+	for _, part := range msg.parts {
+		full.Payload = append(full.Payload, part...)
+	}
+	if m := metrics.GetGlobalMetrics(); m != nil {
+		m.RecordMessageReassembled()
+	}
+	return full, true, nil
+}
+
+// deliver hands an in-order packet to the processor, reassembling and decompressing messages first
+func deliver(pkt ml.Packet, window *Window, processor PacketProcessor, logf Logger) {
+	if pkt.Flags&ml.FLAG_FRAGMENT != 0 {
+		full, complete, err := window.reassemble(pkt)
+		if err != nil {
+			logf("WARN", "Invalid fragment discarded", map[string]any{
+				"seq":   pkt.SeqNum,
+				"error": err,
+			})
+			return
+		}
+		if !complete {
+			return
+		}
+		pkt = full
+	}
+	if pkt.Flags&ml.FLAG_COMPRESSED != 0 {
+		full, err := window.decompress(pkt)
+		if err != nil {
+			logf("WARN", "Invalid compressed payload discarded", map[string]any{
+				"seq":   pkt.SeqNum,
+				"error": err,
+			})
+			return
+		}
+		pkt = full
+	}
+	go processor(pkt)
+}
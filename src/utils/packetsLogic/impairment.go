@@ -0,0 +1,118 @@
+package packetslogic
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Network impairments.
+// An Impairer decides the fate of each datagram crossing a degraded link: lost, duplicated,
+// corrupted, delayed or held back so later ones overtake it. It drives the in-memory transport
+// (see memtransport.go) and the netem-proxy command. Random decisions come from a seeded
+// generator, so a given sequence of datagrams is always impaired the same way.
+
+// Impairment describes how a link degrades the datagrams crossing it
+type Impairment struct {
+	Loss         float64             // Probability of dropping a datagram
+	Duplicate    float64             // Probability of delivering a datagram twice
+	Corrupt      float64             // Probability of flipping one bit of a delivered datagram
+	Reorder      float64             // Probability of holding a datagram back by ReorderDelay, so later ones overtake it
+	ReorderDelay time.Duration       // Extra delay of reordered datagrams
+	Delay        time.Duration       // One-way delay of every datagram
+	Jitter       time.Duration       // Random extra delay, up to Jitter
+	Drop         func(b []byte) bool // Deterministic losses: datagrams for which it returns true are dropped
+}
+
+// ImpairmentStats counts what an Impairer did to the datagrams it handled
+type ImpairmentStats struct {
+	Sent       uint64 // Datagrams handled
+	Dropped    uint64 // Datagrams lost
+	Duplicated uint64 // Datagrams delivered twice
+	Corrupted  uint64 // Copies delivered with a flipped bit
+	Reordered  uint64 // Copies held back by ReorderDelay
+}
+
+// Delivery is a copy of a datagram to deliver after Delay
+type Delivery struct {
+	Data  []byte
+	Delay time.Duration
+}
+
+// Impairer applies an Impairment to datagrams
+type Impairer struct {
+	mu         sync.Mutex
+	impairment Impairment
+	rng        *rand.Rand
+	stats      ImpairmentStats
+}
+
+// NewImpairer creates an Impairer whose random decisions derive from seed
+func NewImpairer(impairment Impairment, seed int64) *Impairer {
+	return &Impairer{impairment: impairment, rng: rand.New(rand.NewSource(seed))}
+}
+
+// SetImpairment replaces the impairment of the datagrams handled from now on
+func (i *Impairer) SetImpairment(impairment Impairment) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.impairment = impairment
+}
+
+// Stats returns what the Impairer did so far
+func (i *Impairer) Stats() ImpairmentStats {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.stats
+}
+
+// Impair decides the fate of a datagram: the copies to deliver (none if it is lost)
+// b is never modified; every copy has its own buffer
+func (i *Impairer) Impair(b []byte) []Delivery {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	imp := i.impairment
+	i.stats.Sent++
+
+	if (imp.Drop != nil && imp.Drop(b)) || i.chance(imp.Loss) {
+		i.stats.Dropped++
+		return nil
+	}
+
+	copies := 1
+	if i.chance(imp.Duplicate) {
+		i.stats.Duplicated++
+		copies = 2
+	}
+	deliveries := make([]Delivery, copies)
+	for n := range deliveries {
+		data := append([]byte(nil), b...)
+		if len(data) > 0 && i.chance(imp.Corrupt) {
+			i.stats.Corrupted++
+			bit := i.rng.Intn(len(data) * 8)
+			data[bit/8] ^= 1 << (bit % 8)
+		}
+		deliveries[n] = Delivery{Data: data, Delay: i.delay(imp)}
+	}
+	return deliveries
+}
+
+// chance draws a random event of probability p
+// Caller must hold i.mu
+func (i *Impairer) chance(p float64) bool {
+	return p > 0 && i.rng.Float64() < p
+}
+
+// delay draws the transit time of a datagram
+// Caller must hold i.mu
+func (i *Impairer) delay(imp Impairment) time.Duration {
+	delay := imp.Delay
+	if imp.Jitter > 0 {
+		delay += time.Duration(i.rng.Int63n(int64(imp.Jitter) + 1))
+	}
+	if i.chance(imp.Reorder) {
+		i.stats.Reordered++
+		delay += imp.ReorderDelay
+	}
+	return delay
+}
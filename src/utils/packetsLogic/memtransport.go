@@ -0,0 +1,227 @@
+package packetslogic
+
+import (
+	"container/heap"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// In-memory transport.
+// A MemNetwork connects MemConns by address without any socket, so the reliability layer
+// can be exercised in tests. Each MemConn degrades the datagrams it sends with an Impairer
+// (see impairment.go) seeded by the network seed and the conn's port, so a given sequence
+// of writes is always impaired the same way.
+
+// ErrAddrInUse is returned by MemNetwork.Listen for an address already listening
+var ErrAddrInUse = errors.New("address already in use")
+
+// MemNetwork is an in-memory datagram network
+type MemNetwork struct {
+	seed  int64
+	mu    sync.Mutex
+	conns map[string]*MemConn
+}
+
+// NewMemNetwork creates an empty network whose impairments derive from seed
+func NewMemNetwork(seed int64) *MemNetwork {
+	return &MemNetwork{seed: seed, conns: make(map[string]*MemConn)}
+}
+
+// Listen creates a conn receiving the datagrams sent to addr
+func (n *MemNetwork) Listen(addr *net.UDPAddr, impairment Impairment) (*MemConn, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, taken := n.conns[addr.String()]; taken {
+		return nil, ErrAddrInUse
+	}
+
+	c := &MemConn{
+		network:  n,
+		addr:     addr,
+		impairer: NewImpairer(impairment, n.seed+int64(addr.Port)),
+		ready:    make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	n.conns[addr.String()] = c
+	return c, nil
+}
+
+// lookup returns the conn listening on addr, if any
+func (n *MemNetwork) lookup(addr *net.UDPAddr) *MemConn {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.conns[addr.String()]
+}
+
+// MemConn is a PacketConn of a MemNetwork
+// It is meant for a single reader, like the receive loops of the rover and the mothership
+type MemConn struct {
+	network  *MemNetwork
+	addr     *net.UDPAddr
+	impairer *Impairer // Impairs the datagrams sent by this conn
+
+	mu          sync.Mutex
+	unreachable uint64        // Datagrams sent to an address nobody listens on
+	inbox       datagramQueue // Datagrams in transit to this conn, ordered by arrival time
+	arrivals    uint64        // Datagrams queued so far (keeps simultaneous arrivals in order)
+	closed      bool
+
+	ready chan struct{} // Signals a new datagram in the inbox
+	done  chan struct{} // Closed by Close
+}
+
+// LocalAddr returns the address the conn listens on
+func (c *MemConn) LocalAddr() *net.UDPAddr {
+	return c.addr
+}
+
+// SetImpairment replaces the impairment of the datagrams sent from now on
+func (c *MemConn) SetImpairment(impairment Impairment) {
+	c.impairer.SetImpairment(impairment)
+}
+
+// Stats returns what happened to the datagrams sent so far
+// Datagrams sent to an address nobody listens on count as dropped
+func (c *MemConn) Stats() ImpairmentStats {
+	stats := c.impairer.Stats()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats.Dropped += c.unreachable
+	return stats
+}
+
+// WriteToUDP sends a datagram to the conn listening on addr, impaired as configured
+// As with UDP, datagrams to an address nobody listens on are silently lost
+func (c *MemConn) WriteToUDP(b []byte, addr *net.UDPAddr) (int, error) {
+	c.mu.Lock()
+	closed := c.closed
+	c.mu.Unlock()
+	if closed {
+		return 0, net.ErrClosed
+	}
+
+	deliveries := c.impairer.Impair(b)
+	dst := c.network.lookup(addr)
+	if dst == nil {
+		if len(deliveries) > 0 {
+			c.mu.Lock()
+			c.unreachable++
+			c.mu.Unlock()
+		}
+		return len(b), nil
+	}
+
+	now := time.Now()
+	for _, d := range deliveries {
+		dst.push(d.Data, c.addr, now.Add(d.Delay))
+	}
+	return len(b), nil
+}
+
+// push queues a datagram arriving at due
+func (c *MemConn) push(data []byte, from *net.UDPAddr, due time.Time) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	c.arrivals++
+	heap.Push(&c.inbox, &datagram{data: data, from: from, due: due, order: c.arrivals})
+	c.mu.Unlock()
+
+	select {
+	case c.ready <- struct{}{}:
+	default:
+		// A wake-up is already pending
+	}
+}
+
+// ReadFromUDP blocks until the next datagram arrives
+func (c *MemConn) ReadFromUDP(b []byte) (int, *net.UDPAddr, error) {
+	for {
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			return 0, nil, net.ErrClosed
+		}
+		wait := time.Duration(-1) // Nothing in transit
+		if len(c.inbox) > 0 {
+			if wait = time.Until(c.inbox[0].due); wait <= 0 {
+				d := heap.Pop(&c.inbox).(*datagram)
+				c.mu.Unlock()
+				return copy(b, d.data), d.from, nil
+			}
+		}
+		c.mu.Unlock()
+
+		if wait < 0 {
+			select {
+			case <-c.ready:
+			case <-c.done:
+			}
+			continue
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-c.ready:
+		case <-c.done:
+		}
+		timer.Stop()
+	}
+}
+
+// Close stops the conn: blocked and future reads and writes fail with net.ErrClosed
+func (c *MemConn) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return net.ErrClosed
+	}
+	c.closed = true
+	c.inbox = nil
+	close(c.done)
+	c.mu.Unlock()
+
+	c.network.mu.Lock()
+	delete(c.network.conns, c.addr.String())
+	c.network.mu.Unlock()
+	return nil
+}
+
+// datagram is a datagram in transit
+type datagram struct {
+	data  []byte
+	from  *net.UDPAddr
+	due   time.Time // Arrival time
+	order uint64    // Queueing order, breaks ties between simultaneous arrivals
+}
+
+// datagramQueue is a min-heap of datagrams ordered by arrival time
+type datagramQueue []*datagram
+
+func (q datagramQueue) Len() int { return len(q) }
+func (q datagramQueue) Less(i, j int) bool {
+	if q[i].due.Equal(q[j].due) {
+		return q[i].order < q[j].order
+	}
+	return q[i].due.Before(q[j].due)
+}
+func (q datagramQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *datagramQueue) Push(x any) {
+	*q = append(*q, x.(*datagram))
+}
+
+func (q *datagramQueue) Pop() any {
+	old := *q
+	n := len(old)
+	d := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return d
+}
+
+var _ PacketConn = (*MemConn)(nil)
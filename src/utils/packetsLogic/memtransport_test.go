@@ -0,0 +1,101 @@
+package packetslogic
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+// received drains the datagrams that reached conn within wait
+func received(t *testing.T, conn *MemConn, wait time.Duration) []string {
+	t.Helper()
+	got := make(chan string, 1024)
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, _, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				close(got)
+				return
+			}
+			got <- string(buf[:n])
+		}
+	}()
+	time.Sleep(wait)
+	conn.Close()
+
+	var datagrams []string
+	for d := range got {
+		datagrams = append(datagrams, d)
+	}
+	return datagrams
+}
+
+// impairedRun sends 200 datagrams over a fresh network and returns what arrived
+func impairedRun(t *testing.T, seed int64) ([]string, ImpairmentStats) {
+	network := NewMemNetwork(seed)
+	a, err := network.Listen(senderAddr, Impairment{Loss: 0.2, Duplicate: 0.1, Reorder: 0.1, ReorderDelay: 5 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := network.Listen(receiverAddr, Impairment{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 200; i++ {
+		if _, err := a.WriteToUDP([]byte(fmt.Sprintf("datagram %03d", i)), receiverAddr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return received(t, b, 30*time.Millisecond), a.Stats()
+}
+
+func TestMemNetworkDeterministic(t *testing.T) {
+	first, stats := impairedRun(t, 42)
+	second, _ := impairedRun(t, 42)
+
+	if stats.Dropped == 0 || stats.Duplicated == 0 || stats.Reordered == 0 {
+		t.Fatalf("impairments not applied: %+v", stats)
+	}
+	if want := int(stats.Sent - stats.Dropped + stats.Duplicated); len(first) != want {
+		t.Fatalf("received %d datagrams, want %d (%+v)", len(first), want, stats)
+	}
+	if fmt.Sprint(first) != fmt.Sprint(second) {
+		t.Fatalf("same seed, different deliveries:\n%v\n%v", first, second)
+	}
+}
+
+func TestMemConnClose(t *testing.T) {
+	network := NewMemNetwork(0)
+	conn, err := network.Listen(receiverAddr, Impairment{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := network.Listen(receiverAddr, Impairment{}); !errors.Is(err, ErrAddrInUse) {
+		t.Fatalf("second listen on %s: %v, want ErrAddrInUse", receiverAddr, err)
+	}
+
+	done := make(chan error)
+	go func() {
+		_, _, err := conn.ReadFromUDP(make([]byte, 16))
+		done <- err
+	}()
+	conn.Close()
+	select {
+	case err := <-done:
+		if !errors.Is(err, net.ErrClosed) {
+			t.Fatalf("read after close: %v, want net.ErrClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("read still blocked after close")
+	}
+
+	// The address is free again
+	if _, err := network.Listen(receiverAddr, Impairment{}); err != nil {
+		t.Fatalf("listen after close: %v", err)
+	}
+}
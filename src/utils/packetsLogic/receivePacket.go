@@ -1,7 +1,10 @@
 package packetslogic
 
 import (
+	"fmt"
+	"math"
 	"net"
+	"sort"
 	"src/config"
 	"src/internal/ml"
 	"src/utils/metrics"
@@ -20,12 +23,18 @@ func seqGreaterThan(seq1, seq2 uint32) bool {
 	return int16(seq1-seq2) > 0
 }
 
+// seqInBlock reports whether seq falls inside the SACK block [Start, End)
+func seqInBlock(seq uint32, block ml.SackBlock) bool {
+	return !seqLessThan(seq, block.Start) && seqLessThan(seq, block.End)
+}
 
-
-// ProcessAckNum processes an AckNum and signals waiting goroutines
+// ProcessAckNum processes an AckNum and releases acknowledged packets from the window
 // This handles both explicit ACKs and implicit ACKs (e.g., MSG_MISSION responding to MSG_REQUEST)
+// ackData is the payload of an explicit ACK (nil for implicit ACKs):
+// its SACK blocks release the matching in-flight packets immediately, even on duplicate ACKs,
+// and its receive window limits how many packets may be in flight
 // Implements Fast Retransmit: counts duplicate ACKs and triggers retransmit after threshold
-func ProcessAckNum(ackNum uint32, window *Window) {
+func ProcessAckNum(ackNum uint32, ackData *ml.AckData, window *Window) {
 	if ackNum == 0 {
 		return // No ACK to process
 	}
@@ -33,6 +42,31 @@ func ProcessAckNum(ackNum uint32, window *Window) {
 	window.Mu.Lock()
 	defer window.Mu.Unlock()
 
+	var sackBlocks []ml.SackBlock
+	if ackData != nil {
+		sackBlocks = ackData.SackBlocks
+		// Reordered (older) ACKs carry a stale receive window
+		if !seqLessThan(ackNum, window.LastAckNum) {
+			window.setRwnd(int(ackData.Window))
+		}
+	}
+
+	// Selectively acknowledged packets stop being retransmitted right away
+	if len(sackBlocks) > 0 {
+		for seqKey, entry := range window.Window {
+			for _, block := range sackBlocks {
+				if seqInBlock(seqKey, block) {
+					window.release(seqKey, entry)
+					window.growCwnd()
+					if m := metrics.GlobalMetrics; m != nil {
+						m.RecordSackedPacket()
+					}
+					break
+				}
+			}
+		}
+	}
+
 	// Check for duplicate ACK (same AckNum as last one)
 	if ackNum == window.LastAckNum && ackNum > 0 {
 		// Increment duplicate ACK counter
@@ -44,14 +78,15 @@ func ProcessAckNum(ackNum uint32, window *Window) {
 			// Find the packet that needs retransmission (the one with SeqNum = AckNum)
 			// AckNum indicates "I expect byte AckNum next", so packet at SeqNum=AckNum is missing
 			if entry, exists := window.Window[uint32(ackNum)]; exists {
-				select {
-				case entry.FastRetransmit <- true:
-					// Signal sent successfully
-				default:
-					// Channel full, fast retransmit already triggered
+				if window.triggerFastRetransmit(entry) {
+					// Retransmission scheduled, halve the congestion window
+					window.shrinkCwnd(ackNum, false)
 				}
 			}
 		}
+		if len(sackBlocks) > 0 {
+			window.notifySlotFreed()
+		}
 		return // Don't process further for duplicate ACKs
 	}
 
@@ -65,14 +100,12 @@ func ProcessAckNum(ackNum uint32, window *Window) {
 	// In TCP-style, AckNum represents the next byte expected
 	for seqKey, entry := range window.Window {
 		if seqLessThan(uint32(seqKey), ackNum) {
-			select {
-			case entry.AckChan <- 1: // Signal ACK received
-			default:
-				// Channel might be full or closed
-			}
-			delete(window.Window, seqKey)
+			window.release(seqKey, entry)
+			window.growCwnd()
 		}
 	}
+	window.exitRecovery(ackNum)
+	window.notifySlotFreed()
 
 	// Update LastAckReceived considering wraparound
 	if seqGreaterThan(ackNum-1, uint32(window.LastAckReceived)) {
@@ -80,10 +113,43 @@ func ProcessAckNum(ackNum uint32, window *Window) {
 	}
 }
 
+// DecodePacket deserializes a datagram and verifies its CRC-32C checksum
+// Returns false for truncated or corrupted datagrams, which must be discarded
+// before any header field (RoverId, SeqNum, ...) is trusted
+func DecodePacket(data []byte, addr *net.UDPAddr, logf Logger) (ml.Packet, bool) {
+	var pkt ml.Packet
+	if err := pkt.Decode(data); err != nil {
+		logf("ERROR", "Truncated packet, discarded", map[string]any{
+			"addr":  addr.String(),
+			"size":  len(data),
+			"error": err.Error(),
+		})
+		if m := metrics.GlobalMetrics; m != nil {
+			m.RecordChecksumFailed()
+		}
+		return pkt, false
+	}
+
+	if expected := pkt.ComputeChecksum(); pkt.Checksum != expected {
+		logf("ERROR", "Invalid checksum, packet discarded", map[string]any{
+			"addr":     addr.String(),
+			"expected": expected,
+			"received": pkt.Checksum,
+		})
+		// Record metric
+		if m := metrics.GlobalMetrics; m != nil {
+			m.RecordChecksumFailed()
+		}
+		return pkt, false
+	}
+	return pkt, true
+}
+
 // PacketProcessor is the callback function to process a packet after ordering
 type PacketProcessor func(pkt ml.Packet)
 
-// HandleOrderedPacket processes packets with ordering and checksum verification
+// HandleOrderedPacket processes packets with ordering and MAC verification
+// The checksum must already have been verified by DecodePacket
 // Parameters:
 //   - pkt: received packet
 //   - expectedSeq: pointer to the expected sequence number
@@ -91,16 +157,16 @@ type PacketProcessor func(pkt ml.Packet)
 //   - mu: mutex to protect state access
 //   - conn: UDP connection
 //   - addr: sender's address
-//   - window: flow control window
+//   - window: flow control window (also holds the peer's session keys)
 //   - roverID: rover ID (0 for MotherShip)
 //   - processor: callback function to process the packet
-//   - skipOrdering: if true, process without ordering (e.g., ACKs)
+//   - skipOrdering: if true, process without ordering (e.g., ACKs and errors)
 func HandleOrderedPacket(
 	pkt ml.Packet,
 	expectedSeq *uint32,
 	buffer map[uint32]ml.Packet,
 	mu *sync.Mutex,
-	conn *net.UDPConn,
+	conn PacketConn,
 	addr *net.UDPAddr,
 	window *Window,
 	roverID uint8,
@@ -109,18 +175,33 @@ func HandleOrderedPacket(
 	autoAck bool,
 	logf func(level string, msg string, meta any),
 ) {
-	// Verify checksum before any processing
-	expectedChecksum := ml.Checksum(pkt.Payload)
-	if pkt.Checksum != expectedChecksum {
-		logf("ERROR", "Invalid checksum, packet discarded", map[string]any{
-			"addr":     addr.String(),
-			"expected": expectedChecksum,
-			"received": pkt.Checksum,
+	// Verify the packet was signed with this peer's key
+	if !pkt.VerifyMAC(window.Keys().AuthKey) {
+		logf("ERROR", "Unauthenticated packet, discarded", map[string]any{
+			"addr":    addr.String(),
+			"roverId": pkt.RoverId,
+			"type":    pkt.MsgType.String(),
 		})
 		// Record metric
 		if m := metrics.GlobalMetrics; m != nil {
-			m.RecordChecksumFailed()
+			m.RecordAuthFailed()
+		}
+		return
+	}
+
+	// Refuse incompatible protocol versions (errors are always read, and never answered)
+	// Only authenticated peers are answered, so spoofed datagrams can't make us send signed errors
+	if !ml.IsVersionSupported(pkt.Version) && pkt.MsgType != ml.MSG_ERROR {
+		logf("ERROR", "Unsupported protocol version, packet refused", map[string]any{
+			"addr":    addr.String(),
+			"version": pkt.Version,
+		})
+		if m := metrics.GlobalMetrics; m != nil {
+			m.RecordVersionRejected()
 		}
+		SendError(conn, addr, ml.ERR_UNSUPPORTED_VERSION,
+			fmt.Sprintf("unsupported protocol version %d (supported: %d-%d)", pkt.Version, ml.MIN_PROTOCOL_VERSION, ml.PROTOCOL_VERSION),
+			window, roverID, logf)
 		return
 	}
 
@@ -130,10 +211,59 @@ func HandleOrderedPacket(
 		m.RecordPacketReceived(pkt.MsgType.String(), packetSize)
 	}
 
+	// Forward error correction (see fec.go): a parity packet, or a retransmission completing
+	// a parity group, may rebuild lost packets, which are then handled as if just received
+	recovered := window.receiveFEC(pkt, logf)
+	if pkt.MsgType != ml.MSG_FEC {
+		orderPacket(pkt, expectedSeq, buffer, mu, conn, addr, window, roverID, processor, skipOrdering, autoAck, logf)
+	}
+	for _, lost := range recovered {
+		orderPacket(lost, expectedSeq, buffer, mu, conn, addr, window, roverID, processor, false, true, logf)
+	}
+}
+
+// orderPacket decrypts an authenticated packet, processes its ACK and delivers it in order
+// (see HandleOrderedPacket)
+func orderPacket(
+	pkt ml.Packet,
+	expectedSeq *uint32,
+	buffer map[uint32]ml.Packet,
+	mu *sync.Mutex,
+	conn PacketConn,
+	addr *net.UDPAddr,
+	window *Window,
+	roverID uint8,
+	processor PacketProcessor,
+	skipOrdering bool,
+	autoAck bool,
+	logf func(level string, msg string, meta any),
+) {
+	// Decrypt payload (sequence accounting below always uses the plaintext size)
+	if err := window.Keys().Cipher.Open(&pkt); err != nil {
+		logf("ERROR", "Failed to decrypt payload, packet discarded", map[string]any{
+			"addr":  addr.String(),
+			"seq":   pkt.SeqNum,
+			"error": err,
+		})
+		if m := metrics.GlobalMetrics; m != nil {
+			m.RecordAuthFailed()
+		}
+		return
+	}
+
 	// ALWAYS process implicit ACK if AckNum > 0
 	// This handles MSG_MISSION/MSG_NO_MISSION acting as ACK for MSG_REQUEST
+	// Explicit ACKs may also carry SACK blocks in their payload
 	if pkt.AckNum > 0 {
-		ProcessAckNum(pkt.AckNum, window)
+		var ackData *ml.AckData
+		if pkt.MsgType == ml.MSG_ACK {
+			ackData = &ml.AckData{}
+			if err := ackData.Decode(pkt.Payload); err != nil {
+				logf("WARN", "Malformed ACK payload ignored", map[string]any{"error": err})
+				ackData = nil
+			}
+		}
+		ProcessAckNum(pkt.AckNum, ackData, window)
 	}
 
 	// If processing without ordering (pure ACKs), we're done after processing AckNum
@@ -159,8 +289,8 @@ func HandleOrderedPacket(
 
 	switch {
 	case seq == expected:
-		// Expected packet - process and advance window
-		go processor(pkt)
+		// Expected packet - process (or reassemble) and advance window
+		deliver(pkt, window, processor, logf)
 		*expectedSeq = nextExpected
 
 		// Process consecutive buffered packets WITHOUT sending individual ACKs
@@ -168,7 +298,7 @@ func HandleOrderedPacket(
 		for {
 			if bufferedPkt, ok := buffer[*expectedSeq]; ok {
 				delete(buffer, *expectedSeq)
-				go processor(bufferedPkt)
+				deliver(bufferedPkt, window, processor, logf)
 				// Calculate buffered packet size (minimum 1 for empty payloads)
 				bufferedPayloadSize := len(bufferedPkt.Payload)
 				if bufferedPayloadSize == 0 {
@@ -190,13 +320,25 @@ func HandleOrderedPacket(
 					"originalSeq":   seq,
 				})
 			}
-			SendAck(conn, addr, *expectedSeq, window, roverID, logf)
+			SendAck(conn, addr, *expectedSeq, buildAckData(buffer, *expectedSeq, window), window, roverID, logf)
 		}
 
 	case seqGreaterThan(seq, expected):
-		// Out-of-order packet - buffer and send cumulative ACK
-		buffer[seq] = pkt
-		SendAck(conn, addr, expected, window, roverID, logf)
+		// Out-of-order packet - buffer and send cumulative ACK (with SACK blocks for the buffered ranges)
+		// A full buffer drops the packet: the sender retransmits it once the receive window reopens
+		if _, buffered := buffer[seq]; !buffered && len(buffer) >= config.REORDER_BUFFER_SIZE {
+			logf("WARN", "Reorder buffer full, packet dropped", map[string]any{
+				"seq":      seq,
+				"expected": expected,
+				"buffered": len(buffer),
+			})
+			if m := metrics.GlobalMetrics; m != nil {
+				m.RecordReorderDrop()
+			}
+		} else {
+			buffer[seq] = pkt
+		}
+		SendAck(conn, addr, expected, buildAckData(buffer, expected, window), window, roverID, logf)
 		// Record out-of-order metric
 		if m := metrics.GlobalMetrics; m != nil {
 			m.RecordOutOfOrder()
@@ -204,10 +346,65 @@ func HandleOrderedPacket(
 
 	case seqLessThan(seq, expected):
 		// Duplicate packet - resend ACK
-		SendAck(conn, addr, nextExpected, window, roverID, logf)
+		SendAck(conn, addr, nextExpected, buildAckData(buffer, expected, window), window, roverID, logf)
 		// Record duplicate metric
 		if m := metrics.GlobalMetrics; m != nil {
 			m.RecordDuplicateReceived()
 		}
 	}
 }
+
+// buildAckData builds the payload of an ACK for expected: the receive window left in
+// buffer and, when SACK was negotiated, the buffered ranges
+// Must be called with the ordering mutex held
+func buildAckData(buffer map[uint32]ml.Packet, expected uint32, window *Window) ml.AckData {
+	return ml.AckData{
+		Window:     advertisedWindow(buffer),
+		SackBlocks: buildSackBlocks(buffer, expected, window),
+	}
+}
+
+// advertisedWindow returns how many more out-of-order packets buffer can hold
+func advertisedWindow(buffer map[uint32]ml.Packet) uint16 {
+	free := config.REORDER_BUFFER_SIZE - len(buffer)
+	if free < 0 {
+		return 0
+	}
+	if free > math.MaxUint16 {
+		return math.MaxUint16
+	}
+	return uint16(free)
+}
+
+// buildSackBlocks describes the out-of-order packets held in buffer as contiguous ranges
+// beyond expected, closest ranges first. Returns nil unless SACK was negotiated with the peer.
+// Must be called with the ordering mutex held
+func buildSackBlocks(buffer map[uint32]ml.Packet, expected uint32, window *Window) []ml.SackBlock {
+	if len(buffer) == 0 || !window.HasCapability(ml.CAP_SACK) {
+		return nil
+	}
+
+	// Sort buffered SeqNums by distance from expected (handles wraparound)
+	seqs := make([]uint32, 0, len(buffer))
+	for seq := range buffer {
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool {
+		return seqs[i]-expected < seqs[j]-expected
+	})
+
+	// Merge adjacent packets into blocks
+	var blocks []ml.SackBlock
+	for _, seq := range seqs {
+		end := CalculateAckNum(buffer[seq])
+		if n := len(blocks); n > 0 && blocks[n-1].End == seq {
+			blocks[n-1].End = end
+			continue
+		}
+		if len(blocks) == ml.MAX_SACK_BLOCKS {
+			break
+		}
+		blocks = append(blocks, ml.SackBlock{Start: seq, End: end})
+	}
+	return blocks
+}
@@ -0,0 +1,313 @@
+package packetslogic
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"src/config"
+	"src/internal/ml"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Reliability tests: a sender and a receiver window talking over a MemNetwork.
+// Every message is checked to be delivered exactly once, in order, whatever the network does.
+
+const (
+	testRoverID = 1
+	testISN     = 1000
+)
+
+var (
+	senderAddr   = &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 4001}
+	receiverAddr = &net.UDPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 4002}
+)
+
+func TestMain(m *testing.M) {
+	config.MTU = 1200
+	config.INITIAL_RTO = 50 * time.Millisecond
+	config.MIN_RTO = 20 * time.Millisecond
+	config.MAX_RTO = 200 * time.Millisecond
+	config.MAX_RETRIES = 20
+	config.MAX_PACKETS_IN_FLIGHT = 16
+	config.FAST_RETRANSMIT_THRESH = 3
+	config.INITIAL_CWND = 8
+	config.REORDER_BUFFER_SIZE = 32
+	os.Exit(m.Run())
+}
+
+// withRTO overrides the RTO bounds for a single test
+func withRTO(t *testing.T, initial, min, max time.Duration) {
+	prevInitial, prevMin, prevMax := config.INITIAL_RTO, config.MIN_RTO, config.MAX_RTO
+	config.INITIAL_RTO, config.MIN_RTO, config.MAX_RTO = initial, min, max
+	t.Cleanup(func() {
+		config.INITIAL_RTO, config.MIN_RTO, config.MAX_RTO = prevInitial, prevMin, prevMax
+	})
+}
+
+// endpoint is one side of a MissionLink session running on a MemConn
+type endpoint struct {
+	conn     *MemConn
+	peer     *net.UDPAddr
+	window   *Window
+	seq      uint32 // Next SeqNum to send
+	expected uint32 // Next SeqNum expected from the peer
+	buffer   map[uint32]ml.Packet
+	mu       sync.Mutex
+
+	deliveredMu sync.Mutex
+	delivered   []ml.Packet
+}
+
+func nopLogf(string, string, any) {}
+
+// newEndpoint listens on addr and starts its receive loop
+func newEndpoint(t *testing.T, network *MemNetwork, addr, peer *net.UDPAddr, impairment Impairment) *endpoint {
+	t.Helper()
+	conn, err := network.Listen(addr, impairment)
+	if err != nil {
+		t.Fatalf("listen %s: %v", addr, err)
+	}
+	e := &endpoint{
+		conn:     conn,
+		peer:     peer,
+		window:   NewWindow(0, &ml.SessionKeys{AuthKey: []byte("memory transport test key")}),
+		seq:      testISN,
+		expected: testISN,
+		buffer:   make(map[uint32]ml.Packet),
+	}
+	e.window.SetCapabilities(ml.CAP_SACK)
+	t.Cleanup(func() {
+		e.window.Close()
+		conn.Close()
+	})
+	go e.receive()
+	return e
+}
+
+// receive is the endpoint's receive loop, like the rover's
+func (e *endpoint) receive() {
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := e.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		pkt, ok := DecodePacket(buf[:n], addr, nopLogf)
+		if !ok {
+			continue
+		}
+		unsequenced := pkt.MsgType.IsUnsequenced()
+		HandleOrderedPacket(pkt, &e.expected, e.buffer, &e.mu, e.conn, addr, e.window, testRoverID,
+			e.record, unsequenced, !unsequenced, nopLogf)
+	}
+}
+
+// record is the endpoint's packet processor
+func (e *endpoint) record(pkt ml.Packet) {
+	if pkt.MsgType == ml.MSG_ACK {
+		return
+	}
+	e.deliveredMu.Lock()
+	defer e.deliveredMu.Unlock()
+	e.delivered = append(e.delivered, pkt)
+}
+
+// send sends a report to the peer
+func (e *endpoint) send(t *testing.T, payload []byte) {
+	t.Helper()
+	err := CreateAndSendPacket(context.Background(), e.conn, e.peer, testRoverID, ml.MSG_REPORT,
+		&e.seq, 0, payload, e.window, nil, nopLogf)
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+}
+
+// messages returns count distinct test payloads
+func messages(count int) [][]byte {
+	msgs := make([][]byte, count)
+	for i := range msgs {
+		msgs[i] = []byte(fmt.Sprintf("report %03d", i))
+	}
+	return msgs
+}
+
+// expectDelivered waits until the receiver delivered msgs and the sender's window drained,
+// then checks every message was delivered exactly once, in SeqNum order
+// Processors run on their own goroutines, so deliveries are compared sorted by SeqNum
+func expectDelivered(t *testing.T, sender, receiver *endpoint, msgs [][]byte, timeout time.Duration) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := sender.window.WaitUntilIdle(ctx); err != nil {
+		t.Fatalf("window not drained after %v: %d packets in flight", timeout, sender.window.GetPendingCount())
+	}
+
+	var delivered []ml.Packet
+	for {
+		receiver.deliveredMu.Lock()
+		delivered = append(delivered[:0], receiver.delivered...)
+		receiver.deliveredMu.Unlock()
+		if len(delivered) >= len(msgs) || ctx.Err() != nil {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond) // Let late duplicates show up
+
+	receiver.deliveredMu.Lock()
+	delivered = append(delivered[:0], receiver.delivered...)
+	receiver.deliveredMu.Unlock()
+	if len(delivered) != len(msgs) {
+		t.Fatalf("delivered %d messages, want %d", len(delivered), len(msgs))
+	}
+	sort.Slice(delivered, func(i, j int) bool {
+		return seqLessThan(delivered[i].SeqNum, delivered[j].SeqNum)
+	})
+	for i, pkt := range delivered {
+		if string(pkt.Payload) != string(msgs[i]) {
+			t.Fatalf("message %d is %q, want %q", i, pkt.Payload, msgs[i])
+		}
+	}
+}
+
+// dropFirst returns a Drop function losing the first transmission of the report with SeqNum seq,
+// and the number of times that report was transmitted
+func dropFirst(seq uint32) (func(b []byte) bool, func() int) {
+	var mu sync.Mutex
+	transmissions := 0
+	drop := func(b []byte) bool {
+		var pkt ml.Packet
+		if pkt.Decode(b) != nil || pkt.MsgType != ml.MSG_REPORT || pkt.SeqNum != seq {
+			return false
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		transmissions++
+		return transmissions == 1
+	}
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return transmissions
+	}
+	return drop, count
+}
+
+// seqOf returns the SeqNum of message i of msgs, sent from testISN
+func seqOf(msgs [][]byte, i int) uint32 {
+	seq := uint32(testISN)
+	for _, msg := range msgs[:i] {
+		seq += uint32(len(msg))
+	}
+	return seq
+}
+
+func TestRetransmissionAfterTimeout(t *testing.T) {
+	network := NewMemNetwork(1)
+	msgs := messages(3)
+
+	// The last report is lost: no later packet produces duplicate ACKs, only the RTO recovers it
+	drop, transmissions := dropFirst(seqOf(msgs, 2))
+	sender := newEndpoint(t, network, senderAddr, receiverAddr, Impairment{Drop: drop})
+	receiver := newEndpoint(t, network, receiverAddr, senderAddr, Impairment{})
+
+	start := time.Now()
+	for _, msg := range msgs {
+		sender.send(t, msg)
+	}
+	expectDelivered(t, sender, receiver, msgs, 2*time.Second)
+
+	if got := transmissions(); got != 2 {
+		t.Errorf("lost report transmitted %d times, want 2", got)
+	}
+	if elapsed := time.Since(start); elapsed < config.MIN_RTO {
+		t.Errorf("lost report recovered after %v, before the RTO", elapsed)
+	}
+}
+
+func TestFastRetransmit(t *testing.T) {
+	// An RTO far beyond the test deadline: only duplicate ACKs can recover the loss in time
+	withRTO(t, 5*time.Second, 5*time.Second, 5*time.Second)
+
+	network := NewMemNetwork(2)
+	msgs := messages(8)
+	drop, transmissions := dropFirst(seqOf(msgs, 2))
+	sender := newEndpoint(t, network, senderAddr, receiverAddr, Impairment{Drop: drop})
+	receiver := newEndpoint(t, network, receiverAddr, senderAddr, Impairment{})
+	sender.window.SetCapabilities(0) // Without SACK, only duplicate ACKs signal the loss
+
+	for _, msg := range msgs {
+		sender.send(t, msg)
+	}
+	expectDelivered(t, sender, receiver, msgs, time.Second)
+
+	if got := transmissions(); got != 2 {
+		t.Errorf("lost report transmitted %d times, want 2", got)
+	}
+	if cwnd, _ := sender.window.GetCwnd(); cwnd >= float64(config.INITIAL_CWND+len(msgs)) {
+		t.Errorf("cwnd %.1f not reduced after fast retransmit", cwnd)
+	}
+}
+
+func TestReordering(t *testing.T) {
+	network := NewMemNetwork(3)
+	msgs := messages(50)
+	sender := newEndpoint(t, network, senderAddr, receiverAddr, Impairment{
+		Reorder:      0.3,
+		ReorderDelay: 5 * time.Millisecond,
+		Jitter:       time.Millisecond,
+	})
+	receiver := newEndpoint(t, network, receiverAddr, senderAddr, Impairment{})
+
+	for _, msg := range msgs {
+		sender.send(t, msg)
+	}
+	expectDelivered(t, sender, receiver, msgs, 2*time.Second)
+
+	if stats := sender.conn.Stats(); stats.Reordered == 0 {
+		t.Errorf("no datagram reordered: %+v", stats)
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	network := NewMemNetwork(4)
+	msgs := messages(30)
+	sender := newEndpoint(t, network, senderAddr, receiverAddr, Impairment{Duplicate: 0.5})
+	receiver := newEndpoint(t, network, receiverAddr, senderAddr, Impairment{Duplicate: 0.5})
+
+	for _, msg := range msgs {
+		sender.send(t, msg)
+	}
+	expectDelivered(t, sender, receiver, msgs, 2*time.Second)
+
+	if stats := sender.conn.Stats(); stats.Duplicated == 0 {
+		t.Errorf("no datagram duplicated: %+v", stats)
+	}
+}
+
+func TestLossyLink(t *testing.T) {
+	network := NewMemNetwork(5)
+	msgs := messages(100)
+	impairment := Impairment{
+		Loss:         0.1,
+		Duplicate:    0.05,
+		Reorder:      0.1,
+		ReorderDelay: 3 * time.Millisecond,
+		Delay:        time.Millisecond,
+	}
+	sender := newEndpoint(t, network, senderAddr, receiverAddr, impairment)
+	receiver := newEndpoint(t, network, receiverAddr, senderAddr, impairment)
+
+	for _, msg := range msgs {
+		sender.send(t, msg)
+	}
+	expectDelivered(t, sender, receiver, msgs, 10*time.Second)
+
+	if stats := sender.conn.Stats(); stats.Dropped == 0 {
+		t.Errorf("no datagram lost: %+v", stats)
+	}
+}
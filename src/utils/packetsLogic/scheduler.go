@@ -0,0 +1,225 @@
+package packetslogic
+
+import (
+	"container/heap"
+	"net"
+	"src/config"
+	"src/internal/ml"
+	"src/utils/metrics"
+	"time"
+)
+
+// Per-peer retransmission scheduler.
+// Every in-flight packet of a Window lives in a min-heap keyed by its retransmission deadline.
+// A single goroutine per Window sleeps until the earliest deadline (or until woken by a new
+// packet or a fast retransmit) and resends whatever is due. The goroutine exits as soon as
+// the window is empty and is restarted by the next registered packet.
+
+// retransmitQueue is a min-heap of in-flight packets ordered by Deadline
+type retransmitQueue []*PacketEntry
+
+func (q retransmitQueue) Len() int           { return len(q) }
+func (q retransmitQueue) Less(i, j int) bool { return q[i].Deadline.Before(q[j].Deadline) }
+
+func (q retransmitQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *retransmitQueue) Push(x any) {
+	entry := x.(*PacketEntry)
+	entry.index = len(*q)
+	*q = append(*q, entry)
+}
+
+func (q *retransmitQueue) Pop() any {
+	old := *q
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil
+	entry.index = -1
+	*q = old[:n-1]
+	return entry
+}
+
+// transmission is a send decided under the window lock and performed after releasing it
+type transmission struct {
+	entry  *PacketEntry
+	packet ml.Packet
+	addr   *net.UDPAddr // Peer address when the send was decided (see MigrateAddr)
+}
+
+// schedule adds a new entry to the retransmission queue and makes sure the scheduler runs
+// Caller must hold w.Mu
+func (w *Window) schedule(entry *PacketEntry) {
+	heap.Push(&w.timers, entry)
+	if !w.schedulerRunning {
+		w.schedulerRunning = true
+		go w.runScheduler()
+		return
+	}
+	if entry.index == 0 {
+		w.wakeScheduler() // New earliest deadline
+	}
+}
+
+// wakeScheduler interrupts the scheduler's sleep so it re-reads the earliest deadline
+func (w *Window) wakeScheduler() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+		// A wake-up is already pending
+	}
+}
+
+// release removes an acknowledged packet from the window and the retransmission queue
+// The RTT is sampled only for packets that were never retransmitted (Karn's algorithm)
+// Caller must hold w.Mu
+func (w *Window) release(seqNum uint32, entry *PacketEntry) {
+	w.unschedule(seqNum, entry)
+
+	m := metrics.GetGlobalMetrics()
+	if !entry.Retransmitted {
+		rtt := time.Since(entry.FirstSent)
+		w.UpdateRTO(rtt)
+		if m != nil {
+			m.RecordRTT(rtt)
+		}
+	}
+	if m != nil {
+		m.RecordAckReceived()
+	}
+}
+
+// unschedule removes a packet from the window without any RTT bookkeeping
+// Caller must hold w.Mu
+func (w *Window) unschedule(seqNum uint32, entry *PacketEntry) {
+	delete(w.Window, seqNum)
+	if entry.index >= 0 {
+		heap.Remove(&w.timers, entry.index)
+	}
+}
+
+// triggerFastRetransmit moves a packet to the front of the queue for immediate resending
+// Returns false if a fast retransmit of this packet is already pending
+// Caller must hold w.Mu
+func (w *Window) triggerFastRetransmit(entry *PacketEntry) bool {
+	if entry.fastRetransmit || entry.index < 0 {
+		return false
+	}
+	entry.fastRetransmit = true
+	entry.Deadline = time.Now()
+	heap.Fix(&w.timers, entry.index)
+	w.wakeScheduler()
+	return true
+}
+
+// runScheduler is the retransmission loop of a Window
+func (w *Window) runScheduler() {
+	for {
+		w.Mu.Lock()
+		if len(w.timers) == 0 {
+			w.schedulerRunning = false
+			w.Mu.Unlock()
+			return
+		}
+		due, givenUp := w.collectDue(time.Now())
+		var next time.Duration
+		if len(w.timers) > 0 {
+			next = time.Until(w.timers[0].Deadline)
+		}
+		onGiveUp := w.onGiveUp
+		w.Mu.Unlock()
+
+		for _, t := range due {
+			w.retransmit(t)
+		}
+		if onGiveUp != nil {
+			for _, entry := range givenUp {
+				onGiveUp(UndeliveredMessage{MsgType: entry.Packet.MsgType, Payload: entry.message})
+			}
+		}
+		if len(due) > 0 || len(givenUp) > 0 {
+			continue // Deadlines may have passed while sending
+		}
+
+		timer := time.NewTimer(next)
+		select {
+		case <-timer.C:
+		case <-w.wake:
+			timer.Stop()
+		}
+	}
+}
+
+// collectDue handles every packet whose deadline has passed: fast retransmits and timeouts
+// are rescheduled and returned for sending, packets out of retries are dropped and returned
+// separately
+// Caller must hold w.Mu
+func (w *Window) collectDue(now time.Time) (due []transmission, givenUp []*PacketEntry) {
+	for len(w.timers) > 0 && !w.timers[0].Deadline.After(now) {
+		entry := w.timers[0]
+		seqNum := entry.Packet.SeqNum
+
+		if entry.fastRetransmit {
+			// Fast retransmit doesn't consume a retry, cwnd was already reduced by ProcessAckNum
+			entry.fastRetransmit = false
+			entry.logf("WARN", "Fast Retransmit triggered", map[string]any{
+				"seqNum": seqNum,
+			})
+		} else {
+			// Timeout - back off the congestion window
+			w.shrinkCwnd(seqNum, true)
+			if entry.Retries == config.MAX_RETRIES {
+				if m := metrics.GetGlobalMetrics(); m != nil {
+					m.RecordPacketLost()
+				}
+				handleMaxRetriesReached(seqNum, entry.logf)
+				w.unschedule(seqNum, entry)
+				w.notifySlotFreed()
+				givenUp = append(givenUp, entry)
+				continue
+			}
+			handleTimeout(seqNum, entry.Retries, w.RTO, entry.logf)
+			entry.Retries++
+		}
+
+		// Don't update RTO from this packet anymore
+		entry.Retransmitted = true
+		entry.Deadline = now.Add(w.RTO)
+		heap.Fix(&w.timers, entry.index)
+		due = append(due, transmission{entry: entry, packet: entry.Packet, addr: entry.addr})
+	}
+	return due, givenUp
+}
+
+// retransmit resends a packet collected by collectDue
+func (w *Window) retransmit(t transmission) {
+	packetSize, err := SendPacketUDP(t.entry.conn, t.addr, t.packet, w.Keys())
+	if err != nil {
+		t.entry.logf("ERROR", "Failed to send packet", map[string]any{
+			"seqNum": t.packet.SeqNum,
+			"error":  err,
+		})
+		unregisterPacket(w, t.packet.SeqNum, t.entry)
+		return
+	}
+
+	if m := metrics.GetGlobalMetrics(); m != nil {
+		m.RecordPacketSent(t.packet.MsgType.String(), packetSize)
+		m.RecordRetransmission()
+	}
+}
+
+// newPacketEntry creates the in-flight state of a packet about to be sent for the first time
+func newPacketEntry(conn PacketConn, addr *net.UDPAddr, pkt ml.Packet, message []byte, logf Logger) *PacketEntry {
+	return &PacketEntry{
+		Packet:  pkt,
+		message: message,
+		conn:    conn,
+		addr:    addr,
+		logf:    logf,
+		index:   -1,
+	}
+}
@@ -1,50 +1,125 @@
 package packetslogic
 
 import (
+	"compress/flate"
+	"context"
+	"errors"
 	"net"
 	"src/config"
 	"src/internal/ml"
 	"src/utils/metrics"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Logger is a function type for logging messages
 type Logger func(level, msg string, meta any)
 
-// PacketEntry holds the channel and fast retransmit signal for a packet in flight
+// ErrWindowClosed is returned to senders of a window whose session was closed
+var ErrWindowClosed = errors.New("window closed")
+
+// UndeliveredMessage is a message the peer never acknowledged (see Window.SetGiveUpHandler)
+type UndeliveredMessage struct {
+	MsgType ml.PacketType
+	Payload []byte // Whole message, even if it was fragmented
+}
+
+// PacketEntry holds the retransmission state of a packet in flight (see scheduler.go)
 type PacketEntry struct {
-	AckChan        chan int8 // Signal when ACK is received
-	FastRetransmit chan bool // Signal for fast retransmit
+	Packet        ml.Packet // Packet as handed to PacketManager (before encryption)
+	FirstSent     time.Time // Time of the first transmission, used for RTT samples
+	Deadline      time.Time // Time of the next retransmission
+	Retries       int       // Retransmissions caused by timeouts so far
+	Retransmitted bool      // Whether the packet was ever resent (Karn's algorithm)
+
+	fastRetransmit bool   // Fast retransmit requested by duplicate ACKs
+	index          int    // Position in the retransmission queue (-1 when not queued)
+	message        []byte // Whole message carried (shared by all fragments of a message)
+	conn           PacketConn
+	addr           *net.UDPAddr
+	logf           Logger
 }
 
 // Window is the sliding window structure to manage sent packets and RTO calculation
 type Window struct {
-	LastAckReceived int32                  // Last ACK received number
-	Window          map[uint32]*PacketEntry // Sent packets not yet ACKed
-	DupAckCount     map[uint32]int          // Count of duplicate ACKs per AckNum
-	LastAckNum      uint32                  // Last received AckNum (to detect duplicates)
-	Mu              sync.Mutex              // Mutex for concurrent access
+	Peer            uint8                          // ID of the peer: the rover on the MotherShip, 0 on a rover
+	LastAckReceived int32                          // Last ACK received number
+	Window          map[uint32]*PacketEntry        // Sent packets not yet ACKed
+	DupAckCount     map[uint32]int                 // Count of duplicate ACKs per AckNum
+	LastAckNum      uint32                         // Last received AckNum (to detect duplicates)
+	Mu              sync.Mutex                     // Mutex for concurrent access
+	keys            atomic.Pointer[ml.SessionKeys] // Session keys shared with the peer (HMAC key and optional AEAD)
+	Capabilities    uint8                          // Capabilities negotiated with the peer via HELLO (ml.CAP_*)
+	Codec           ml.Codec                       // Payload compression codec (see compression.go)
 	// Fields for dynamic RTO calculation
 	SRTT   time.Duration
 	RTTVAR time.Duration
 	RTO    time.Duration
+	// Fields for congestion control (see congestion.go)
+	Cwnd        float64 // Congestion window in packets
+	Ssthresh    float64 // Slow start threshold in packets
+	HighestSent uint32  // Highest SeqNum registered in the window
+	RecoverSeq  uint32  // HighestSent when the last loss was detected
+	InRecovery  bool    // Whether a loss reduction is in progress
+	Rwnd        int     // Receive window advertised by the peer in its last ACK (packets)
+
+	slotFreed chan struct{} // Closed (and replaced) whenever a slot may have been freed
+	closed    bool          // Set by Close: the session is over, nothing else is sent
+
+	onGiveUp func(UndeliveredMessage) // Called for every message given up after MAX_RETRIES
+
+	// Retransmission scheduler (see scheduler.go)
+	timers           retransmitQueue // In-flight packets ordered by retransmission deadline
+	schedulerRunning bool            // Whether the scheduler goroutine is alive
+	wake             chan struct{}   // Interrupts the scheduler's sleep
+
+	// Fragmentation (see fragment.go)
+	nextMessageID uint16                     // MessageID of the next message fragmented towards the peer
+	fragments     map[uint16]*partialMessage // Messages from the peer being reassembled
+
+	// Forward error correction (see fec.go)
+	fecOut       *fecEncoder       // Parity of the reports being sent (nil when not protecting)
+	fecSeen      map[uint32][]byte // Wire payloads of the peer's latest packets, by SeqNum
+	fecSeenOrder []uint32          // SeqNums in fecSeen, oldest first
+	fecPending   []*fecGroup       // Peer's parity groups still missing several packets
 }
 
-const chanBufferSize = 1
-
 // NewWindow creates and initializes a new Window instance
-func NewWindow() *Window {
-	return &Window{
+// peer is the ID of the other end (0 for the MotherShip), and keys are the per-rover
+// session keys negotiated during the ID handshake
+func NewWindow(peer uint8, keys *ml.SessionKeys) *Window {
+	w := &Window{
+		Peer:            peer,
 		LastAckReceived: -1,
 		Window:          make(map[uint32]*PacketEntry),
 		DupAckCount:     make(map[uint32]int),
 		LastAckNum:      0,
 		Mu:              sync.Mutex{},
+		Codec:           ml.FlateCodec{Level: flate.BestSpeed},
 		SRTT:            0,
 		RTTVAR:          0,
 		RTO:             config.INITIAL_RTO, // initial fallback from config
+		Cwnd:            float64(config.INITIAL_CWND),
+		Ssthresh:        float64(config.MAX_PACKETS_IN_FLIGHT),
+		Rwnd:            config.REORDER_BUFFER_SIZE, // Assume the peer uses the same buffer until it advertises one
+		slotFreed:       make(chan struct{}),
+		wake:            make(chan struct{}, 1),
+		fragments:       make(map[uint16]*partialMessage),
+		fecSeen:         make(map[uint32][]byte),
 	}
+	w.keys.Store(keys)
+	return w
+}
+
+// Keys returns the session keys packets are currently signed and encrypted with
+func (w *Window) Keys() *ml.SessionKeys {
+	return w.keys.Load()
+}
+
+// SetKeys switches the window to the keys of a new session (see ml.SessionKeys.ForSession)
+func (w *Window) SetKeys(keys *ml.SessionKeys) {
+	w.keys.Store(keys)
 }
 
 // GetPendingCount returns the number of packets waiting for ACK
@@ -54,14 +129,187 @@ func (w *Window) GetPendingCount() int {
 	return len(w.Window)
 }
 
-// WaitForWindowSlot blocks until there's room in the sliding window
-// This implements flow control to prevent overwhelming the receiver
-func (w *Window) WaitForWindowSlot() {
+// GetRTO returns the current retransmission timeout
+func (w *Window) GetRTO() time.Duration {
+	w.Mu.Lock()
+	defer w.Mu.Unlock()
+	return w.RTO
+}
+
+// LocalCapabilities returns the capabilities this side can offer to the peer
+func (w *Window) LocalCapabilities() uint8 {
+	caps := ml.SUPPORTED_CAPABILITIES
+	if keys := w.Keys(); keys != nil && keys.Cipher != nil {
+		caps |= ml.CAP_ENCRYPTION
+	}
+	if config.COMPRESSION_ENABLED {
+		caps |= ml.CAP_COMPRESSION
+	}
+	if config.FEC_ENABLED {
+		caps |= ml.CAP_FEC
+	}
+	return caps
+}
+
+// SetCapabilities stores the capabilities negotiated with the peer
+func (w *Window) SetCapabilities(caps uint8) {
+	w.Mu.Lock()
+	defer w.Mu.Unlock()
+	w.Capabilities = caps
+}
+
+// HasCapability reports whether a capability was negotiated with the peer
+func (w *Window) HasCapability(capability uint8) bool {
+	w.Mu.Lock()
+	defer w.Mu.Unlock()
+	return w.Capabilities&capability != 0
+}
+
+// notifySlotFreed wakes every sender blocked in WaitForWindowSlot
+// Caller must hold w.Mu
+func (w *Window) notifySlotFreed() {
+	close(w.slotFreed)
+	w.slotFreed = make(chan struct{})
+}
+
+// setRwnd stores the receive window advertised by the peer, waking blocked senders if it grew
+// Caller must hold w.Mu
+func (w *Window) setRwnd(rwnd int) {
+	grew := rwnd > w.Rwnd
+	w.Rwnd = rwnd
+	if grew {
+		w.notifySlotFreed()
+	}
+}
+
+// sendLimit returns how many packets may be in flight: min(cwnd, rwnd, MAX_PACKETS_IN_FLIGHT)
+// At least one packet is always allowed, so a closed receive window is probed by the
+// next in-order packet (which the peer accepts without buffering it)
+// Caller must hold w.Mu
+func (w *Window) sendLimit() int {
+	limit := w.congestionLimit()
+	if w.Rwnd < limit {
+		limit = w.Rwnd
+	}
+	if limit < 1 {
+		limit = 1
+	}
+	return limit
+}
+
+// Close ends the session of this window: in-flight packets stop being retransmitted
+// and blocked or future senders get ErrWindowClosed
+func (w *Window) Close() {
+	w.Mu.Lock()
+	defer w.Mu.Unlock()
+	if w.closed {
+		return
+	}
+	w.closed = true
+	for seqNum, entry := range w.Window {
+		w.unschedule(seqNum, entry)
+	}
+	w.fragments = make(map[uint16]*partialMessage)
+	w.resetFEC()
+	w.wakeScheduler()
+	w.notifySlotFreed()
+}
+
+// SetGiveUpHandler registers the function called, outside the window lock, with every
+// message given up after MAX_RETRIES. Fragmented messages may be reported once per lost fragment
+func (w *Window) SetGiveUpHandler(handler func(UndeliveredMessage)) {
+	w.Mu.Lock()
+	defer w.Mu.Unlock()
+	w.onGiveUp = handler
+}
+
+// Reset discards every packet in flight and the congestion and reassembly state, so the
+// window can carry a new session with the same peer and keys
+// Returns the messages that were still waiting for an ACK
+func (w *Window) Reset() []UndeliveredMessage {
+	w.Mu.Lock()
+	defer w.Mu.Unlock()
+
+	var pending []UndeliveredMessage
+	seen := make(map[*byte]bool) // Fragments of a message share its payload
+	for seqNum, entry := range w.Window {
+		w.unschedule(seqNum, entry)
+		if len(entry.message) > 0 {
+			if seen[&entry.message[0]] {
+				continue
+			}
+			seen[&entry.message[0]] = true
+		}
+		pending = append(pending, UndeliveredMessage{MsgType: entry.Packet.MsgType, Payload: entry.message})
+	}
+
+	w.LastAckReceived = -1
+	w.DupAckCount = make(map[uint32]int)
+	w.LastAckNum = 0
+	w.Cwnd = float64(config.INITIAL_CWND)
+	w.Ssthresh = float64(config.MAX_PACKETS_IN_FLIGHT)
+	w.InRecovery = false
+	w.Rwnd = config.REORDER_BUFFER_SIZE
+	w.fragments = make(map[uint16]*partialMessage)
+	w.resetFEC()
+	w.wakeScheduler()
+	w.notifySlotFreed()
+	return pending
+}
+
+// MigrateAddr redirects the retransmissions of every packet in flight to the peer's new address
+func (w *Window) MigrateAddr(addr *net.UDPAddr) {
+	w.Mu.Lock()
+	defer w.Mu.Unlock()
+	for _, entry := range w.Window {
+		entry.addr = addr
+	}
+}
+
+// WaitUntilIdle blocks until every packet in flight has been acknowledged (or given up on)
+// Returns ctx.Err() if the context ends first
+func (w *Window) WaitUntilIdle(ctx context.Context) error {
+	for {
+		w.Mu.Lock()
+		if len(w.Window) == 0 {
+			w.Mu.Unlock()
+			return nil
+		}
+		freed := w.slotFreed
+		w.Mu.Unlock()
+
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// WaitForWindowSlot blocks until there's room in min(cwnd, rwnd, MAX_PACKETS_IN_FLIGHT)
+// This implements flow and congestion control to prevent overwhelming the receiver and the path
+// Blocked senders are woken by ProcessAckNum and unregisterPacket; returns ctx.Err() if the
+// context is cancelled or times out first, and ErrWindowClosed once the window is closed
+func (w *Window) WaitForWindowSlot(ctx context.Context) error {
 	for {
-		if w.GetPendingCount() < config.MAX_PACKETS_IN_FLIGHT {
-			return
+		w.Mu.Lock()
+		if w.closed {
+			w.Mu.Unlock()
+			return ErrWindowClosed
+		}
+		if len(w.Window) < w.sendLimit() {
+			w.Mu.Unlock()
+			return nil
+		}
+		freed := w.slotFreed
+		w.Mu.Unlock()
+
+		select {
+		case <-freed:
+			// Re-check: another sender may have taken the slot
+		case <-ctx.Done():
+			return ctx.Err()
 		}
-		time.Sleep(10 * time.Millisecond)
 	}
 }
 
@@ -95,22 +343,35 @@ func absDuration(d time.Duration) time.Duration {
 	return d
 }
 
-// SendPacketUDP encodes and sends a packet through UDP connection
-func SendPacketUDP(conn *net.UDPConn, addr *net.UDPAddr, packet ml.Packet) error {
-	// Encodes the packet
-	packet.Checksum = ml.Checksum(packet.Payload)
+// SendPacketUDP encrypts, signs, encodes and sends a packet through UDP connection
+// Returns the number of bytes written on the wire
+func SendPacketUDP(conn PacketConn, addr *net.UDPAddr, packet ml.Packet, keys *ml.SessionKeys) (int, error) {
+	// Encrypt-then-MAC: payload is sealed first, then MAC and checksum cover the ciphertext
+	packet = sealPacket(packet, keys)
+	packet.Sign(keys.AuthKey)
+	packet.Checksum = packet.ComputeChecksum()
 	encodedPacket := packet.Encode()
 
 	// Sends the encoded data to the specified address port
-	_, error := conn.WriteToUDP(encodedPacket, addr)
-	return error
+	return conn.WriteToUDP(encodedPacket, addr)
+}
+
+// sealPacket returns the packet as put on the wire, before signing: current version and
+// payload encrypted if the session uses an AEAD
+func sealPacket(packet ml.Packet, keys *ml.SessionKeys) ml.Packet {
+	packet.Version = ml.PROTOCOL_VERSION
+	keys.Cipher.Seal(&packet)
+	return packet
 }
 
 // CreateAndSendPacket creates a packet with auto-incremented SeqNum and sends it
 // This is a generic function that handles both rover and mothership packet sending
 // SeqNum is incremented by the total payload
+// Payloads larger than the MTU are split into fragments (see fragment.go)
+// Returns an error if ctx ends while waiting for a window slot
 func CreateAndSendPacket(
-	conn *net.UDPConn,
+	ctx context.Context,
+	conn PacketConn,
 	addr *net.UDPAddr,
 	roverID uint8,
 	msgType ml.PacketType,
@@ -120,10 +381,42 @@ func CreateAndSendPacket(
 	window *Window,
 	windowLock *sync.Mutex,
 	logf Logger,
-) {
-	// Flow control: wait if too many packets are in flight (except for ACKs)
-	if msgType != ml.MSG_ACK {
-		window.WaitForWindowSlot()
+) error {
+	message := payload // Kept uncompressed for the give-up handler
+	flags := uint8(0)
+	if compressed, ok := window.compress(msgType, payload); ok {
+		payload = compressed
+		flags |= ml.FLAG_COMPRESSED
+	}
+
+	if window.needsFragmentation(msgType, payload) {
+		return sendFragmented(ctx, conn, addr, roverID, msgType, flags, seqNum, ackNum, payload, message, window, windowLock, logf)
+	}
+	return sendSegment(ctx, conn, addr, roverID, msgType, flags, seqNum, ackNum, payload, message, window, windowLock, logf)
+}
+
+// sendSegment sends a single packet with the given header flags (see CreateAndSendPacket)
+// message is the whole message the payload belongs to, reported if the packet is given up
+func sendSegment(
+	ctx context.Context,
+	conn PacketConn,
+	addr *net.UDPAddr,
+	roverID uint8,
+	msgType ml.PacketType,
+	flags uint8,
+	seqNum *uint32,
+	ackNum uint32,
+	payload []byte,
+	message []byte,
+	window *Window,
+	windowLock *sync.Mutex,
+	logf Logger,
+) error {
+	// Flow control: wait if too many packets are in flight (except for ACKs and errors)
+	if !msgType.IsUnsequenced() {
+		if err := window.WaitForWindowSlot(ctx); err != nil {
+			return err
+		}
 	}
 
 	// Lock if mutex is provided (mothership case)
@@ -133,12 +426,12 @@ func CreateAndSendPacket(
 
 	// Create packet with current SeqNum
 	pkt := ml.Packet{
-		RoverId:  roverID,
-		MsgType:  msgType,
-		SeqNum:   *seqNum,
-		AckNum:   ackNum,
-		Checksum: 0,
-		Payload:  payload,
+		Flags:   flags,
+		RoverId: roverID,
+		MsgType: msgType,
+		SeqNum:  *seqNum,
+		AckNum:  ackNum,
+		Payload: payload,
 	}
 
 	// Calculate payload size for SeqNum increment (minimum 1 for empty payloads)
@@ -157,25 +450,36 @@ func CreateAndSendPacket(
 		windowLock.Unlock()
 	}
 
-	// Send packet using PacketManager
-	go PacketManager(conn, addr, pkt, window, logf)
+	// Send packet, handing it to the retransmission scheduler unless it is unsequenced
+	if msgType.IsUnsequenced() {
+		PacketManager(conn, addr, pkt, window, logf)
+	} else {
+		manageRetransmission(conn, addr, pkt, message, window, logf)
+	}
+	return nil
 }
 
-// PacketManager manages the sending and retransmission of a packet until an ACK is received
-func PacketManager(conn *net.UDPConn, addr *net.UDPAddr, pkt ml.Packet, window *Window, logf Logger) {
-	// ACK packets are sent immediately without retransmission
+// PacketManager sends a packet and, unless it is unsequenced (ACK, error, path probe),
+// hands it to the window's retransmission scheduler until an ACK is received
+// It never blocks waiting for the ACK
+func PacketManager(conn PacketConn, addr *net.UDPAddr, pkt ml.Packet, window *Window, logf Logger) {
+	// Unsequenced packets are sent immediately without retransmission
 	if pkt.MsgType == ml.MSG_ACK {
-		sendAckPacket(conn, addr, pkt, logf)
+		sendAckPacket(conn, addr, pkt, window.Keys(), logf)
+		return
+	}
+	if pkt.MsgType.IsUnsequenced() {
+		sendUnsequencedPacket(conn, addr, pkt, window.Keys(), logf)
 		return
 	}
 
 	// For other packets, manage retransmissions with window (non-blocking)
-	manageRetransmission(conn, addr, pkt, window, logf)
+	manageRetransmission(conn, addr, pkt, pkt.Payload, window, logf)
 }
 
 // sendAckPacket sends an ACK packet without waiting for acknowledgment
-func sendAckPacket(conn *net.UDPConn, addr *net.UDPAddr, pkt ml.Packet, logf Logger) {
-	if err := SendPacketUDP(conn, addr, pkt); err != nil {
+func sendAckPacket(conn PacketConn, addr *net.UDPAddr, pkt ml.Packet, keys *ml.SessionKeys, logf Logger) {
+	if _, err := SendPacketUDP(conn, addr, pkt, keys); err != nil {
 		logf("ERROR", "Failed to send ACK", map[string]any{
 			"ackNum": pkt.AckNum,
 			"error":  err,
@@ -185,139 +489,97 @@ func sendAckPacket(conn *net.UDPConn, addr *net.UDPAddr, pkt ml.Packet, logf Log
 		if m := metrics.GetGlobalMetrics(); m != nil {
 			m.RecordAckSent()
 		}
-		logf("INFO", "ACK sent", map[string]any{
+		meta := map[string]any{
 			"ackNum": pkt.AckNum,
-		})
-	}
-}
-
-// manageRetransmission handles sending and retransmitting packets until ACK is received
-func manageRetransmission(conn *net.UDPConn, addr *net.UDPAddr, pkt ml.Packet, window *Window, logf Logger) {
-	// Register packet in window
-	entry := registerPacket(window, pkt.SeqNum)
-	defer unregisterPacket(window, pkt.SeqNum)
-
-	// Record send time only once for RTT measurement (Karn's Algorithm)
-	firstSendTime := time.Now()
-	isFirstAttempt := true
-
-	for retries := 0; retries <= config.MAX_RETRIES; retries++ {
-		// Send the packet
-		if err := SendPacketUDP(conn, addr, pkt); err != nil {
-			logf("ERROR", "Failed to send packet", map[string]any{
-				"seqNum": pkt.SeqNum,
-				"error":  err,
-			})
-			return
 		}
-
-		// Record packet sent metric
-		if m := metrics.GetGlobalMetrics(); m != nil {
-			pktType := ml.PacketType(pkt.MsgType).String()
-			packetSize := ml.PacketHeaderSize + len(pkt.Payload)
-			m.RecordPacketSent(pktType, packetSize)
-
-			// Record retransmission if not first attempt
-			if retries > 0 {
-				m.RecordRetransmission()
+		var ackData ml.AckData
+		if ackData.Decode(pkt.Payload) == nil {
+			meta["rwnd"] = ackData.Window
+			if len(ackData.SackBlocks) > 0 {
+				meta["sack"] = ackData.SackBlocks
 			}
 		}
+		logf("INFO", "ACK sent", meta)
+	}
+}
 
-		if retries == 0 && isFirstAttempt {
-			// Log only on first send, not retries
-			pktType := ml.PacketType(pkt.MsgType).String()
-			logf("INFO", "Packet sent", map[string]any{
-				"type":   pktType,
-				"seqNum": pkt.SeqNum,
-			})
-		}
+// sendUnsequencedPacket sends an error, path or keepalive probe without waiting for acknowledgment
+func sendUnsequencedPacket(conn PacketConn, addr *net.UDPAddr, pkt ml.Packet, keys *ml.SessionKeys, logf Logger) {
+	if _, err := SendPacketUDP(conn, addr, pkt, keys); err != nil {
+		logf("ERROR", "Failed to send packet", map[string]any{
+			"type":  pkt.MsgType.String(),
+			"addr":  addr.String(),
+			"error": err,
+		})
+		return
+	}
+	if m := metrics.GetGlobalMetrics(); m != nil {
+		m.RecordPacketSent(pkt.MsgType.String(), ml.PacketHeaderSize+len(pkt.Payload))
+	}
+}
 
-		// get current RTO value
-		rto := getRTO(window)
+// manageRetransmission registers a packet in the window and sends it for the first time
+// Later retransmissions are performed by the window's scheduler
+func manageRetransmission(conn PacketConn, addr *net.UDPAddr, pkt ml.Packet, message []byte, window *Window, logf Logger) {
+	// Register before sending so that an early ACK always finds the entry
+	entry := registerPacket(window, newPacketEntry(conn, addr, pkt, message, logf))
+	if entry == nil {
+		return // Session closed meanwhile
+	}
 
-		select {
-		case <-entry.AckChan:
-			// ACK received
-			if isFirstAttempt {
-				rtt := time.Since(firstSendTime)
-				handleAckReceived(window, rtt)
-
-				// Record RTT metric only for clean samples
-				if m := metrics.GetGlobalMetrics(); m != nil {
-					m.RecordRTT(rtt)
-				}
-			}
+	packetSize, err := SendPacketUDP(conn, addr, pkt, window.Keys())
+	if err != nil {
+		logf("ERROR", "Failed to send packet", map[string]any{
+			"seqNum": pkt.SeqNum,
+			"error":  err,
+		})
+		unregisterPacket(window, pkt.SeqNum, entry)
+		return
+	}
 
-			// Always record ACK received metric
-			if m := metrics.GetGlobalMetrics(); m != nil {
-				m.RecordAckReceived()
-			}
-			return
-
-		case <-entry.FastRetransmit:
-			// Fast Retransmit triggered - retransmit immediately without waiting for RTO
-			logf("WARN", "Fast Retransmit triggered", map[string]any{
-				"seqNum": pkt.SeqNum,
-			})
-			// Record fast retransmit metric
-			if m := metrics.GetGlobalMetrics(); m != nil {
-				m.RecordRetransmission()
-			}
-			isFirstAttempt = false // Don't update RTO after fast retransmit
-			// Don't increment retries counter for fast retransmit
-			continue
-
-		case <-time.After(rto):
-			// Timeout - prepare for retransmission
-			isFirstAttempt = false
-			if retries == config.MAX_RETRIES {
-				// Record packet lost metric
-				if m := metrics.GetGlobalMetrics(); m != nil {
-					m.RecordPacketLost()
-				}
-				handleMaxRetriesReached(pkt.SeqNum, logf)
-				return
-			}
-			handleTimeout(pkt.SeqNum, retries, rto, logf)
-		}
+	// Record packet sent metric (wire size, including encryption overhead)
+	if m := metrics.GetGlobalMetrics(); m != nil {
+		m.RecordPacketSent(pkt.MsgType.String(), packetSize)
 	}
+	logf("INFO", "Packet sent", map[string]any{
+		"type":   pkt.MsgType.String(),
+		"seqNum": pkt.SeqNum,
+	})
+
+	// Only the first transmission counts towards a parity group (see fec.go)
+	window.protect(conn, addr, pkt, logf)
 }
 
-// registerPacket adds a packet to the window
-func registerPacket(window *Window, seqNum uint32) *PacketEntry {
+// registerPacket adds a packet to the window and schedules its first retransmission
+// Returns nil if the window was closed
+func registerPacket(window *Window, entry *PacketEntry) *PacketEntry {
 	window.Mu.Lock()
 	defer window.Mu.Unlock()
+	if window.closed {
+		return nil
+	}
 
-	entry := &PacketEntry{
-		AckChan:        make(chan int8, chanBufferSize),
-		FastRetransmit: make(chan bool, chanBufferSize),
+	seqNum := entry.Packet.SeqNum
+	entry.FirstSent = time.Now()
+	entry.Deadline = entry.FirstSent.Add(window.RTO)
+	window.Window[seqNum] = entry
+	if len(window.Window) == 1 || seqGreaterThan(seqNum, window.HighestSent) {
+		window.HighestSent = seqNum
 	}
-	window.Window[uint32(seqNum)] = entry
+	window.schedule(entry)
 	return entry
 }
 
-// unregisterPacket removes a packet from the window
-func unregisterPacket(window *Window, seqNum uint32) {
-	window.Mu.Lock()
-	defer window.Mu.Unlock()
-	delete(window.Window, uint32(seqNum))
-}
-
-// getRTO safely retrieves the current RTO value
-func getRTO(window *Window) time.Duration {
+// unregisterPacket removes a packet from the window and wakes blocked senders
+// Does nothing if the packet was already acknowledged
+func unregisterPacket(window *Window, seqNum uint32, entry *PacketEntry) {
 	window.Mu.Lock()
 	defer window.Mu.Unlock()
-	return window.RTO
-}
-
-// handleAckReceived processes a received ACK and updates RTO
-func handleAckReceived(window *Window, rtt time.Duration) {
-	window.Mu.Lock()
-	window.UpdateRTO(rtt)
-	newRTO := window.RTO
-	window.Mu.Unlock()
-	// Note: logging handled by caller if needed
-	_ = newRTO // Keep RTO calculation for future use
+	if window.Window[seqNum] != entry {
+		return
+	}
+	window.unschedule(seqNum, entry)
+	window.notifySlotFreed()
 }
 
 // handleTimeout logs timeout and prepares for retransmission
@@ -339,13 +601,14 @@ func handleMaxRetriesReached(seqNum uint32, logf func(level string, msg string,
 
 // SendAck sends an ACK packet for the given ackNum
 // ackNum should be the next expected byte (currentSeqNum + packetSize)
-func SendAck(conn *net.UDPConn, addr *net.UDPAddr, ackNum uint32, window *Window, roverId uint8, logf func(level string, msg string, meta any)) {
+// ackData carries the receive window and (optional) SACK blocks for data already buffered
+func SendAck(conn PacketConn, addr *net.UDPAddr, ackNum uint32, ackData ml.AckData, window *Window, roverId uint8, logf func(level string, msg string, meta any)) {
 	ackPacket := ml.Packet{
 		RoverId: roverId,
 		MsgType: ml.MSG_ACK,
 		SeqNum:  0,
 		AckNum:  ackNum,
-		Payload: []byte{},
+		Payload: ackData.Encode(),
 	}
 
 	// Use PacketManager to handle sending the ACK packet
@@ -362,4 +625,36 @@ func CalculateAckNum(pkt ml.Packet) uint32 {
 	return pkt.SeqNum + uint32(payloadSize)
 }
 
+// SendError sends a MSG_ERROR packet describing why the peer's packet was refused
+func SendError(conn PacketConn, addr *net.UDPAddr, code uint8, message string, window *Window, roverId uint8, logf Logger) {
+	errData := ml.ErrorData{Code: code, Message: message}
+	errPacket := ml.Packet{
+		RoverId: roverId,
+		MsgType: ml.MSG_ERROR,
+		Payload: errData.Encode(),
+	}
+
+	PacketManager(conn, addr, errPacket, window, logf)
+}
+
+// SendPathData sends a MSG_PATH_CHALLENGE or MSG_PATH_RESPONSE carrying a path validation token
+func SendPathData(conn PacketConn, addr *net.UDPAddr, msgType ml.PacketType, data ml.PathData, window *Window, roverId uint8, logf Logger) {
+	pathPacket := ml.Packet{
+		RoverId: roverId,
+		MsgType: msgType,
+		Payload: data.Encode(),
+	}
+
+	PacketManager(conn, addr, pathPacket, window, logf)
+}
 
+// SendPingData sends a MSG_PING or MSG_PONG keepalive probe
+func SendPingData(conn PacketConn, addr *net.UDPAddr, msgType ml.PacketType, data ml.PingData, window *Window, roverId uint8, logf Logger) {
+	pingPacket := ml.Packet{
+		RoverId: roverId,
+		MsgType: msgType,
+		Payload: data.Encode(),
+	}
+
+	PacketManager(conn, addr, pingPacket, window, logf)
+}
@@ -0,0 +1,21 @@
+package packetslogic
+
+import (
+	"net"
+)
+
+// PacketConn is the datagram transport MissionLink packets travel on.
+// The reliability layer only needs to send to and receive from peer addresses, so it can
+// run on a real UDP socket (*net.UDPConn implements PacketConn) or on a MemNetwork, the
+// in-memory transport that injects loss, delay, duplication and reordering (see memtransport.go).
+type PacketConn interface {
+	// WriteToUDP sends one datagram to addr
+	WriteToUDP(b []byte, addr *net.UDPAddr) (int, error)
+	// ReadFromUDP blocks until a datagram is received, copying it into b
+	ReadFromUDP(b []byte) (int, *net.UDPAddr, error)
+	// Close unblocks readers and releases the transport
+	Close() error
+}
+
+// UDP is the transport used between real rovers and the mothership
+var _ PacketConn = (*net.UDPConn)(nil)